@@ -2,15 +2,11 @@ package app
 
 // C:\_Projects_Go\AcousticMerge\internal\app\app.go
 // Package: app
-// Назначение: Двухпроходный мердж WAV (PCM16), 2 прогресс-бара (PASS1 scan / PASS2 merge), кроссфейд, нормализация.
+// Назначение: Двухпроходный мердж WAV (PCM 8/16/24/32, float 32/64), 2 прогресс-бара (PASS1 scan / PASS2 merge), кроссфейд, нормализация.
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
 	"math"
 	"os"
 	"path/filepath"
@@ -21,24 +17,15 @@ import (
 	"acousticmerge/internal/ui"
 )
 
-type wavPCM struct {
-	AudioFormat   uint16
-	NumChannels   uint16
-	SampleRate    uint32
-	ByteRate      uint32
-	BlockAlign    uint16
-	BitsPerSample uint16
-}
-type wavData struct {
-	PCM  wavPCM
-	Data []int16
-}
-func (w wavData) TotalSamples() int64 { return int64(len(w.Data)) }
-
 type fileInfo struct {
-	Name string
-	Path string
+	Name    string
+	Path    string
 	ModTime time.Time
+	TrimIn  int64     // кадров (на выходной частоте) отброшено в начале
+	TrimOut int64     // ... и в конце
+	Start   time.Time // начало записи (для заполнения пауз; zero — неизвестно)
+	GainDB  float64   // поправка громкости файла, дБ (--list)
+	Gap     int64     // кадров тишины перед файлом (--list)
 }
 
 type Config = ui.Config
@@ -46,56 +33,285 @@ type Config = ui.Config
 func Run(cfg *Config, U ui.UIAPI) {
 	// Параметры
 	U.LogInfo("starting…")
-	U.PrintKV("Source:", cfg.Src)
+	if cfg.List != "" {
+		U.PrintKV("List:", cfg.List)
+	} else {
+		U.PrintKV("Source:", cfg.Src)
+	}
 	U.PrintKV("Output:", cfg.Out)
 	U.PrintKV("Gain:", fmt.Sprintf("%.1f%%", cfg.GainPct))
 	U.PrintKV("Order:", string(cfg.Order))
+	if len(cfg.Include) > 0 { U.PrintKV("Include:", strings.Join(cfg.Include, ", ")) }
+	if len(cfg.Exclude) > 0 { U.PrintKV("Exclude:", strings.Join(cfg.Exclude, ", ")) }
+	if cfg.MaxDepth >= 0 { U.PrintKV("Max depth:", fmt.Sprintf("%d", cfg.MaxDepth)) }
+	if cfg.FollowSymlinks { U.PrintKV("Symlinks:", "follow") }
+	if cfg.From != "" || cfg.To != "" {
+		U.PrintKV("Window:", fmt.Sprintf("%s → %s", orDash(cfg.From), orDash(cfg.To)))
+	}
 	if cfg.DoNormalize {
-		U.PrintKV("Normalize:", fmt.Sprintf("%.2f dBFS", cfg.NormalizeDB))
+		if cfg.TruePeak {
+			U.PrintKV("Normalize:", fmt.Sprintf("%.2f dBTP (true-peak ×4)", cfg.NormalizeDB))
+		} else {
+			U.PrintKV("Normalize:", fmt.Sprintf("%.2f dBFS", cfg.NormalizeDB))
+		}
+	}
+	if cfg.DoLoudness {
+		U.PrintKV("Loudness:", fmt.Sprintf("%.1f LUFS (EBU R128)", cfg.LoudnessLUFS))
 	}
+	if cfg.DoLimiter {
+		U.PrintKV("Limiter:", fmt.Sprintf("%.2f dBFS, attack %g ms, release %g ms", cfg.LimiterCeiling, cfg.LimiterAttackMS, cfg.LimiterReleaseMS))
+	}
+	if cfg.SplitEvery != "" || cfg.SplitSize != "" {
+		U.PrintKV("Split:", fmt.Sprintf("every %s, size %s", orDash(cfg.SplitEvery), orDash(cfg.SplitSize)))
+	}
+	if cfg.OnError != "abort" { U.PrintKV("On error:", cfg.OnError) }
+	if cfg.Repair { U.PrintKV("Repair:", "длина data по размеру файла") }
 	if cfg.CrossfadeMS > 0 {
-		U.PrintKV("Crossfade:", fmt.Sprintf("%d ms", cfg.CrossfadeMS))
+		U.PrintKV("Crossfade:", fmt.Sprintf("%d ms, %s", cfg.CrossfadeMS, cfg.CrossfadeCurve))
 	}
 	fmt.Println()
 
-	// Сбор WAV
-	files, err := collectWavsRecursive(cfg.Src)
+	if cfg.DoNormalize && cfg.DoLoudness {
+		fatal(U, fmt.Errorf("--normalize и --loudness-lufs взаимоисключающие"))
+	}
+	wantJSON, wantCSV, err := indexFormats(cfg.Index)
+	if err != nil { fatal(U, err) }
+	curve, err := parseFadeCurve(cfg.CrossfadeCurve)
+	if err != nil { fatal(U, err) }
+	dither, err := parseDither(cfg.Dither)
 	if err != nil { fatal(U, err) }
-	if len(files) == 0 { fatal(U, fmt.Errorf("в папке %s нет WAV-файлов", cfg.Src)) }
+	var splitEvery time.Duration
+	var splitBytes int64
+	if cfg.SplitEvery != "" {
+		if splitEvery, err = time.ParseDuration(cfg.SplitEvery); err != nil || splitEvery <= 0 {
+			fatal(U, fmt.Errorf("--split-every %q: ожидается длительность, напр. 1h или 30m", cfg.SplitEvery))
+		}
+	}
+	if cfg.SplitSize != "" {
+		if splitBytes, err = parseSize(cfg.SplitSize); err != nil { fatal(U, fmt.Errorf("--split-size: %w", err)) }
+	}
+	switch cfg.TimeSource {
+	case "auto", "name", "bext", "mtime":
+	default:
+		fatal(U, fmt.Errorf("неизвестный --time-source: %s (auto|name|bext|mtime)", cfg.TimeSource))
+	}
+	var nameTime *nameTimeParser
+	switch {
+	case cfg.NameTime != "" && cfg.NameRegex != "":
+		fatal(U, fmt.Errorf("--name-time и --name-regex взаимоисключающие"))
+	case cfg.NameTime != "":
+		if nameTime, err = newNameTimeParser(cfg.NameTime); err != nil { fatal(U, err) }
+	case cfg.NameRegex != "":
+		if nameTime, err = newNameTimeRegex(cfg.NameRegex); err != nil { fatal(U, err) }
+	case cfg.TimeSource == "name" || cfg.Order == ui.OrderByTimestamp:
+		fatal(U, fmt.Errorf("--time-source name и --order timestamp требуют шаблон --name-time или --name-regex"))
+	}
+	var winFrom, winTo time.Time
+	var fromClock, toClock bool
+	if cfg.From != "" {
+		if winFrom, fromClock, err = parseWallClock(cfg.From); err != nil { fatal(U, fmt.Errorf("--from: %w", err)) }
+	}
+	if cfg.To != "" {
+		if winTo, toClock, err = parseWallClock(cfg.To); err != nil { fatal(U, fmt.Errorf("--to: %w", err)) }
+	}
+	// только время суток сравнивается после подстановки даты (в mergeGroup); 23:00 → 01:00 — через полночь
+	switch {
+	case winFrom.IsZero() || winTo.IsZero():
+	case fromClock && toClock && winFrom.Equal(winTo), !fromClock && !toClock && !winFrom.Before(winTo):
+		fatal(U, fmt.Errorf("--from (%s) должно быть раньше --to (%s)", cfg.From, cfg.To))
+	}
+	switch cfg.OnError {
+	case "abort", "skip":
+	case "quarantine":
+		if cfg.QuarantineDir == "" { cfg.QuarantineDir = filepath.Join(filepath.Dir(cfg.Out), "_quarantine") }
+	default:
+		fatal(U, fmt.Errorf("неизвестный --on-error: %s (skip|quarantine|abort)", cfg.OnError))
+	}
+	switch cfg.GroupBy {
+	case "", "dir", "day", "hour":
+	default:
+		fatal(U, fmt.Errorf("неизвестный --group-by: %s (dir|day|hour)", cfg.GroupBy))
+	}
+	opts := mergeOpts{
+		Out:        cfg.Out,
+		WantJSON:   wantJSON,
+		WantCSV:    wantCSV,
+		Curve:      curve,
+		Dither:     dither,
+		SplitEvery: splitEvery,
+		SplitBytes: splitBytes,
+		NameTime:   nameTime,
+		Repair:     cfg.Repair,
+		WinFrom:    winFrom,
+		WinTo:      winTo,
+		FromClock:  fromClock,
+		ToClock:    toClock,
+	}
+
+	// Сбор WAV: явный список (порядок — как в нём) или обход папки
+	var files []fileInfo
+	var listTimings []listTiming
+	if cfg.List != "" {
+		files, listTimings, err = readFileList(cfg.List, cfg.Repair)
+		if err != nil { fatal(U, err) }
+		if len(files) == 0 { fatal(U, fmt.Errorf("в списке %s нет файлов", cfg.List)) }
+	} else {
+		files, err = collectWavsRecursive(cfg.Src, collectOpts{
+			Include:        cfg.Include,
+			Exclude:        cfg.Exclude,
+			MaxDepth:       cfg.MaxDepth,
+			FollowSymlinks: cfg.FollowSymlinks,
+		})
+		if err != nil { fatal(U, err) }
+		if len(files) == 0 { fatal(U, fmt.Errorf("в папке %s нет WAV-файлов", cfg.Src)) }
+	}
 	U.LogInfo("found %d files", len(files))
 
 	// Сортировка
-	switch cfg.Order {
-	case ui.OrderByName:
+	switch {
+	case cfg.List != "":
+		// порядок задан списком
+	case cfg.Order == ui.OrderByName:
 		sort.Slice(files, func(i, j int) bool {
 			return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
 		})
-	case ui.OrderByMTime:
+	case cfg.Order == ui.OrderByMTime:
 		sort.Slice(files, func(i, j int) bool { return files[i].ModTime.Before(files[j].ModTime) })
+	case cfg.Order == ui.OrderByNatural:
+		// относительные пути: вложенные папки day_2/, day_10/ сортируются по уровням
+		rel := func(p string) string {
+			if r, err := filepath.Rel(cfg.Src, p); err == nil { return r }
+			return p
+		}
+		sort.Slice(files, func(i, j int) bool { return naturalPathLess(rel(files[i].Path), rel(files[j].Path)) })
+	case cfg.Order == ui.OrderByTimestamp:
+		// время из имени: mtime теряется при копировании, а счётчики в именах бывают без нулей
+		var bad []string
+		for i := range files {
+			t, ok := nameTime.Parse(files[i].Path)
+			if !ok { bad = append(bad, files[i].Path); continue }
+			files[i].Start = t
+		}
+		if len(bad) > 0 {
+			for _, p := range bad[:min(len(bad), 10)] { U.LogErr("не подходит под шаблон: %s", p) }
+			fatal(U, fmt.Errorf("--order timestamp: %d из %d файлов не подходят под шаблон %q", len(bad), len(files), nameTime.layout))
+		}
+		sort.SliceStable(files, func(i, j int) bool {
+			if !files[i].Start.Equal(files[j].Start) { return files[i].Start.Before(files[j].Start) }
+			return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
+		})
 	default:
 		fatal(U, fmt.Errorf("неизвестный --order: %s", cfg.Order))
 	}
 
+	// Группы: по одному итогу на папку, день или час
+	if cfg.GroupBy == "" {
+		if _, err := mergeGroup(cfg, U, files, listTimings, opts); err != nil { fatal(U, err) }
+		return
+	}
+	groups, err := groupFiles(files, listTimings, cfg.GroupBy, cfg.Src, cfg.TimeSource, nameTime, cfg.Repair)
+	if err != nil { fatal(U, err) }
+	U.PrintKV("Groups:", fmt.Sprintf("%d (%s)", len(groups), cfg.GroupBy))
+	results := make([]groupResult, len(groups))
+	for i, g := range groups {
+		fmt.Println()
+		U.LogInfo("group %d/%d: %s (%d файлов)", i+1, len(groups), g.Key, len(g.Files))
+		o := opts
+		o.Out = groupOutPath(cfg.Out, g.Key)
+		results[i], err = mergeGroup(cfg, U, g.Files, g.Timings, o)
+		if errors.Is(err, errNothingToMerge) {
+			U.LogWarn("group %s: %v", g.Key, err)
+			continue
+		}
+		if err != nil { fatal(U, err) }
+	}
+	printGroupTable(U, groups, results)
+}
+
+// mergeOpts — разобранные параметры одного итога (общие для всех групп, кроме Out).
+type mergeOpts struct {
+	Out        string
+	WantJSON   bool
+	WantCSV    bool
+	Curve      fadeCurve
+	Dither     ditherMode
+	SplitEvery time.Duration
+	SplitBytes int64
+	NameTime   *nameTimeParser
+	Repair     bool // --repair: длина data по размеру файла
+	WinFrom    time.Time
+	WinTo      time.Time
+	FromClock  bool // --from/--to без даты: время суток в день начала первого файла
+	ToClock    bool
+}
+
+// groupResult — что получилось у одного итога (для сводки по группам).
+type groupResult struct {
+	Files    int
+	Duration float64  // секунд
+	Outputs  []string // пусто при --dry-run
+	Skipped  int      // повреждённых файлов пропущено (--on-error)
+}
+
+// errNothingToMerge — после окна/тишины не осталось файлов; в режиме групп такая группа пропускается.
+var errNothingToMerge = errors.New("склеивать нечего")
+
+// mergeGroup — полный цикл для одного набора файлов (уже отсортированных): формат, PASS1, PASS2, индекс.
+// Повреждённые файлы (--on-error skip|quarantine) исключаются до PASS1 или перезапуском PASS1 без них,
+// поэтому PASS2 видит тот же набор и размеры в заголовке сходятся.
+func mergeGroup(cfg *Config, U ui.UIAPI, files []fileInfo, listTimings []listTiming, o mergeOpts) (res groupResult, err error) {
+	bad := &errorPolicy{mode: cfg.OnError}
+	defer func() { res.Skipped = reportBad(cfg, U, bad) }()
+	if bad.mode != "abort" || o.Repair {
+		total := len(files)
+		var repaired []string
+		files, listTimings, err = verifyFiles(files, listTimings, o.Repair, bad,
+			func(fi fileInfo, note string) { repaired = append(repaired, fi.Path+": "+note) },
+			func(done int) { U.PrintBar("PASS0 check:", done, total) })
+		U.EndBar()
+		for _, s := range repaired { U.LogWarn("repaired: %s", s) }
+		if len(repaired) > 0 { U.PrintKV("Repaired:", fmt.Sprintf("%d файлов (длина data восстановлена)", len(repaired))) }
+		if err != nil { fatal(U, err) }
+		if len(files) == 0 { return res, fmt.Errorf("все файлы повреждены: %w", errNothingToMerge) }
+	}
+
 	// Эталон
-	first, refPCM, err := readWav(files[0].Path)
+	refHdr, err := probeWavHeader(files[0].Path, o.Repair)
 	if err != nil { fatal(U, fmt.Errorf("%s: %w", files[0].Path, err)) }
-	if refPCM.AudioFormat != 1 || refPCM.BitsPerSample != 16 {
-		fatal(U, fmt.Errorf("поддерживается только PCM16 (fmt=1, bps=16). Встретили: fmt=%d, bps=%d",
-			refPCM.AudioFormat, refPCM.BitsPerSample))
-	}
+	refPCM := refHdr.PCM
+	if err := checkFormat(refPCM); err != nil { fatal(U, fmt.Errorf("%s: %w", files[0].Path, err)) }
 	channels := int(refPCM.NumChannels)
 	sampleRate := int(refPCM.SampleRate)
-	U.PrintKV("Format:", fmt.Sprintf("%d Hz, %d ch, %d bps (PCM16)",
-		refPCM.SampleRate, refPCM.NumChannels, refPCM.BitsPerSample))
+	U.PrintKV("Format:", fmt.Sprintf("%d Hz, %d ch, %s",
+		refPCM.SampleRate, refPCM.NumChannels, describeFormat(refPCM)))
+	if cfg.Resample < 0 { fatal(U, fmt.Errorf("некорректный --resample: %d", cfg.Resample)) }
+	if cfg.Resample > 0 {
+		sampleRate = cfg.Resample
+		U.PrintKV("Resample:", fmt.Sprintf("→ %d Hz (windowed-sinc)", sampleRate))
+	}
+	if listTimings != nil { applyListTiming(files, listTimings, sampleRate) }
 
-	// Проверка формата strict
+	// Формат вывода — от эталона. Если files[0] потом отбросят окно, тишина или --on-error,
+	// эталоном станет новый первый файл: см. rebase
+	outPCM, err := outputFormat(cfg, refPCM, sampleRate)
+	if err != nil { fatal(U, err) }
+	U.PrintKV("Out format:", describeFormat(outPCM))
+	if o.Dither != ditherNone {
+		if outPCM.AudioFormat == wavFormatFloat {
+			U.LogWarn("dither: итог в float — дизеринг не нужен и не применяется")
+		} else {
+			U.PrintKV("Dither:", cfg.Dither)
+		}
+	}
+
+	// Проверка формата strict (частота не сравнивается, если включён ресемплинг)
 	if cfg.StrictFormat {
 		for i := 1; i < len(files); i++ {
-			_, pcm, err := readWav(files[i].Path)
+			pcm, err := probeWav(files[i].Path, o.Repair)
 			if err != nil { fatal(U, fmt.Errorf("%s: %w", files[i].Path, err)) }
 			if pcm.AudioFormat != refPCM.AudioFormat ||
 				pcm.NumChannels != refPCM.NumChannels ||
-				pcm.SampleRate != refPCM.SampleRate ||
+				(cfg.Resample == 0 && pcm.SampleRate != refPCM.SampleRate) ||
 				pcm.BitsPerSample != refPCM.BitsPerSample {
 				fatal(U, fmt.Errorf("формат файла %s отличается от эталона (strict-mode)", files[i].Name))
 			}
@@ -105,6 +321,26 @@ func Run(cfg *Config, U ui.UIAPI) {
 		}
 	}
 
+	// rebase — эталон после отбрасывания files[0]: заголовок (время начала) и формат вывода берутся
+	// у нового первого файла. Частота и каналы уже заложены в окно, тайминги и кроссфейд, поэтому
+	// они должны совпасть; при --strict-format (по умолчанию) это проверено выше
+	rebase := func() {
+		h, err := probeWavHeader(files[0].Path, o.Repair)
+		if err != nil { fatal(U, fmt.Errorf("%s: %w", files[0].Path, err)) }
+		if err := checkFormat(h.PCM); err != nil { fatal(U, fmt.Errorf("%s: %w", files[0].Path, err)) }
+		if int(h.PCM.NumChannels) != channels || (cfg.Resample == 0 && int(h.PCM.SampleRate) != sampleRate) {
+			fatal(U, fmt.Errorf("новый эталон %s (%d Hz, %d ch) отличается от отброшенного (%d Hz, %d ch) — используйте --strict-format",
+				files[0].Name, h.PCM.SampleRate, h.PCM.NumChannels, sampleRate, channels))
+		}
+		refHdr, refPCM = h, h.PCM
+		p, err := outputFormat(cfg, refPCM, sampleRate)
+		if err != nil { fatal(U, err) }
+		if p != outPCM {
+			outPCM = p
+			U.PrintKV("Out format:", describeFormat(outPCM)+" (эталон "+files[0].Name+")")
+		}
+	}
+
 	// Подготовка кроссфейда
 	fadeTotal := 0
 	if cfg.CrossfadeMS > 0 {
@@ -114,181 +350,305 @@ func Run(cfg *Config, U ui.UIAPI) {
 		}
 	}
 
-	// PASS1: totalSamples и peak
+	// Окно по реальному времени: файлы вне [from; to) отбрасываются, пересекающие — срезаются до сэмпла
+	if !o.WinFrom.IsZero() || !o.WinTo.IsZero() {
+		from, to := o.WinFrom, o.WinTo
+		if o.FromClock || o.ToClock {
+			// время без даты — в день начала первого файла (в группе — первого файла группы)
+			h, err := probeWavHeader(files[0].Path, o.Repair)
+			if err != nil { fatal(U, fmt.Errorf("%s: %w", files[0].Path, err)) }
+			day, _ := fileStart(files[0], h, cfg.TimeSource, o.NameTime)
+			if o.FromClock { from = onDay(from, day) }
+			if o.ToClock {
+				to = onDay(to, day)
+				if !from.IsZero() && !to.After(from) { to = to.AddDate(0, 0, 1) }
+			}
+			if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+				fatal(U, fmt.Errorf("--from (%s) должно быть раньше --to (%s)", from.Format("2006-01-02 15:04:05.000"), to.Format("2006-01-02 15:04:05.000")))
+			}
+			U.PrintKV("Window:", fmt.Sprintf("%s → %s", wallOrDash(from), wallOrDash(to)))
+		}
+		total := len(files)
+		var trimmed int64
+		files, trimmed, err = applyWindow(files, from, to, sampleRate, cfg.TimeSource, o.NameTime, o.Repair)
+		if err != nil { fatal(U, err) }
+		if len(files) == 0 { return res, fmt.Errorf("ни один файл не попадает в окно --from/--to: %w", errNothingToMerge) }
+		U.PrintKV("Window:", fmt.Sprintf("%d из %d файлов, срезано %.3f s", len(files), total, float64(trimmed)/float64(sampleRate)))
+		rebase()
+	}
+
+	// Тишина: тихие файлы отбрасываются, тихие края срезаются — до PASS1, поэтому длительность,
+	// метки и индекс уже не содержат убранного
+	var silence silenceResult
+	if cfg.DoSkipSilent || cfg.DoTrimSilence {
+		total := len(files)
+		files, silence, err = applySilence(files, sampleRate, cfg.SkipSilentDB, cfg.TrimSilenceDB, o.Repair, bad.take,
+			func(done int) { U.PrintBar("PASS1 silence:", done, total) })
+		U.EndBar()
+		if err != nil { fatal(U, err) }
+		U.PrintKV("Silence:", fmt.Sprintf("пропущено %d файлов (%.3f s), срезано %.3f s",
+			len(silence.Dropped), float64(silence.DroppedFrames)/float64(sampleRate), float64(silence.TrimmedFrames)/float64(sampleRate)))
+		if len(files) == 0 { return res, fmt.Errorf("все файлы тише порога: %w", errNothingToMerge) }
+		rebase()
+	}
+
+	// Паузы: время начала каждого файла; сегменты ставятся на своё место, паузы — тишина
+	var tl *timeline
+	if cfg.FillGaps {
+		srcCount := map[string]int{}
+		for i := range files {
+			h, err := probeWavHeader(files[i].Path, o.Repair)
+			if err != nil { fatal(U, fmt.Errorf("%s: %w", files[i].Path, err)) }
+			var src string
+			files[i].Start, src = fileStart(files[i], h, cfg.TimeSource, o.NameTime)
+			srcCount[src]++
+		}
+		tl = &timeline{
+			t0:   files[0].Start.Add(time.Duration(float64(files[0].TrimIn) / float64(sampleRate) * float64(time.Second))),
+			rate: sampleRate,
+			tol:  int64(cfg.GapToleranceMS) * int64(sampleRate) / 1000,
+		}
+		U.PrintKV("Gaps:", fmt.Sprintf("паузы > %d ms → тишина (время: name %d, bext %d, mtime %d)",
+			cfg.GapToleranceMS, srcCount["name"], srcCount["bext"], srcCount["mtime"]))
+		if cfg.TimeSource == "name" && srcCount["name"] < len(files) {
+			U.LogWarn("gaps: %d файлов не подходят под --name-time %q — взято mtime", len(files)-srcCount["name"], cfg.NameTime)
+		}
+	}
+
+	// PASS1: totalSamples и peak (тот же merger, что и в PASS2, — длины совпадают точно).
+	// Файл, не прочитавшийся до конца, при --on-error skip|quarantine убирается, и PASS1 идёт заново
 	gain := float32(cfg.GainPct / 100.0)
 	var totalSamples int64
 	var peak float64
-	var havePrev bool
-	var prevTail []float32
-
-	totalSamples += int64(len(first.Data))
-	if cfg.DoNormalize || fadeTotal > 0 {
-		updatePeakWhole(&peak, first.Data, gain)
-	}
-	if fadeTotal > 0 {
-		prevTail = takeTailAsFloat(first.Data, gain, fadeTotal)
-		havePrev = true
-	}
-
-	U.PrintBar("PASS1 scan:", 1, len(files))
-	for i := 1; i < len(files); i++ {
-		w, _, err := readWav(files[i].Path)
-		if err != nil { fatal(U, fmt.Errorf("%s: %w", files[i].Path, err)) }
-		totalSamples += int64(len(w.Data))
-		if cfg.DoNormalize || fadeTotal > 0 {
-			if fadeTotal > 0 && havePrev && len(w.Data) >= fadeTotal {
-				head := takeHeadAsFloat(w.Data, gain, fadeTotal)
-				updatePeakCrossfade(&peak, prevTail, head)
-				updatePeakWhole(&peak, w.Data[fadeTotal:], gain)
-				prevTail = takeTailAsFloat(w.Data, gain, fadeTotal)
-				havePrev = true
-			} else {
-				updatePeakWhole(&peak, w.Data, gain)
+	var meter *loudnessMeter
+	var tpm *truePeakMeter
+	var spans []segSpan
+	for {
+		totalSamples, peak, meter, tpm = 0, 0, nil, nil
+		switch {
+		case cfg.DoLoudness:
+			meter = newLoudnessMeter(channels, sampleRate)
+			tpm = meter.tp
+		case cfg.DoNormalize || cfg.TruePeak:
+			tpm = newTruePeakMeter(channels)
+		}
+		scan := func(p []float32) error {
+			totalSamples += int64(len(p))
+			if tpm != nil { updatePeak(&peak, p, gain) }
+			if meter != nil {
+				meter.Add(p, gain)
+			} else if tpm != nil {
+				tpm.Add(p, gain)
 			}
+			return nil
+		}
+		spans, err = mergeFiles(files, sampleRate, channels, fadeTotal, o.Curve, tl, o.Repair, scan,
+			func(done int) { U.PrintBar("PASS1 scan:", done, len(files)) })
+		U.EndBar()
+		var fe *fileError
+		if !errors.As(err, &fe) || !bad.take(files[fe.Index], fe.Err) { break }
+		U.LogWarn("%v — файл пропущен, PASS1 заново", err)
+		files = append(files[:fe.Index:fe.Index], files[fe.Index+1:]...)
+		if len(files) == 0 { return res, fmt.Errorf("все файлы повреждены: %w", errNothingToMerge) }
+		if fe.Index == 0 {
+			rebase()
+			if tl != nil { tl.t0 = files[0].Start.Add(time.Duration(float64(files[0].TrimIn) / float64(sampleRate) * float64(time.Second))) }
 		}
-		U.PrintBar("PASS1 scan:", i+1, len(files))
-	}
-	U.EndBar()
-
-	if fadeTotal > 0 && len(files) > 1 {
-		totalSamples -= int64(fadeTotal * (len(files) - 1))
 	}
-	if totalSamples < 0 { totalSamples = 0 }
+	if err != nil { fatal(U, err) }
 
 	// Нормализация
 	scale := float32(1.0)
+	if tpm != nil {
+		U.PrintKV("Peak:", fmt.Sprintf("%.2f dBFS", toDB(peak)))
+		U.PrintKV("True peak:", fmt.Sprintf("%.2f dBTP", toDB(tpm.Peak())))
+	}
 	if cfg.DoNormalize && peak > 0 {
+		// true-peak учитывает межсэмпловые пики, которые появятся после ЦАП
+		ref, unit := peak, "sample"
+		if cfg.TruePeak { ref, unit = tpm.Peak(), "true" }
 		desired := math.Pow(10.0, cfg.NormalizeDB/20.0)
 		if desired > 1.0 { desired = 1.0 }
-		scale = float32(desired / peak)
-		U.LogInfo("normalized: peak %.3f -> %.3f (scale=%.6f)", peak, desired, scale)
+		scale = float32(desired / ref)
+		U.LogInfo("normalized: %s peak %.3f -> %.3f (scale=%.6f)", unit, ref, desired, scale)
+		if !cfg.TruePeak {
+			if tpOut := toDB(tpm.Peak() * float64(scale)); tpOut > 0 {
+				U.LogWarn("normalize: true-peak после нормализации %+.2f dBTP — используйте --true-peak", tpOut)
+			}
+		}
+	}
+	if meter != nil {
+		lufs, lra, tp := meter.Integrated(), meter.Range(), meter.TruePeak()
+		U.PrintKV("Integrated:", fmt.Sprintf("%.1f LUFS", lufs))
+		U.PrintKV("LRA:", fmt.Sprintf("%.1f LU", lra))
+		if math.IsInf(lufs, -1) {
+			U.LogWarn("loudness: нет блоков громче %.0f LUFS (тишина или запись короче 400 мс) — без нормализации", lufsAbsGate)
+		} else {
+			gainDB := cfg.LoudnessLUFS - lufs
+			scale = float32(math.Pow(10.0, gainDB/20.0))
+			U.LogInfo("normalized: %.1f -> %.1f LUFS (gain %+.2f dB, scale=%.6f)", lufs, cfg.LoudnessLUFS, gainDB, scale)
+			if tpOut := toDB(tp) + gainDB; tpOut > 0 {
+				U.LogWarn("loudness: true-peak после нормализации %+.1f dBTP — возможен клиппинг", tpOut)
+			}
+		}
 	}
 
-	// Длительность
+	// Длительность и начало записи (для bext)
 	durSec := float64(totalSamples) / float64(sampleRate*channels)
 	U.PrintKV("Duration:", fmt.Sprintf("%.3f s", durSec))
+	if tl != nil {
+		var gaps, gapSamples int64
+		for _, sp := range spans {
+			if sp.Gap > 0 { gaps++; gapSamples += sp.Gap }
+		}
+		U.PrintKV("Filled:", fmt.Sprintf("%d пауз, %.3f s тишины", gaps, float64(gapSamples)/float64(sampleRate*channels)))
+	}
+	start, startSrc := fileStart(files[0], refHdr, cfg.TimeSource, o.NameTime)
+	start = start.Add(time.Duration(float64(files[0].TrimIn) / float64(sampleRate) * float64(time.Second)))
+	U.PrintKV("Start:", fmt.Sprintf("%s (%s)", start.Format("2006-01-02 15:04:05.000"), startSrc))
+	splitFrames := int64(math.Round(o.SplitEvery.Seconds() * float64(sampleRate)))
+	if o.SplitEvery > 0 && splitFrames < 1 { splitFrames = 1 }
+	if splitFrames > 0 {
+		frames := totalSamples / int64(channels)
+		U.PrintKV("Parts:", fmt.Sprintf("%d по %s", max(1, (frames+splitFrames-1)/splitFrames), o.SplitEvery))
+	}
 	fmt.Println()
 
+	res.Files, res.Duration = len(files), durSec
 	if cfg.DryRun {
 		U.LogOK("dry-run: запись отключена")
-		return
+		return res, nil
 	}
 
-	// Создание вывода
-	outPath, err := nextAvailablePath(cfg.Out)
-	if err != nil { fatal(U, err) }
-	if err := ensureDir(filepath.Dir(outPath)); err != nil { fatal(U, err) }
-	outf, bw, err := createWavWriter(outPath, uint32(sampleRate), uint16(channels), uint32(totalSamples))
+	// Создание вывода: части открываются по ходу PASS2. Метки — по раскладке PASS1 (она совпадает с PASS2):
+	// начало каждого файла в итоге (cue + LIST/adtl)
+	if err := ensureDir(filepath.Dir(o.Out)); err != nil { fatal(U, err) }
+	var markers []wavMarker
+	if cfg.Markers {
+		markers = make([]wavMarker, 0, len(spans))
+		for i, sp := range spans { markers = append(markers, wavMarker{Frame: sp.Start / int64(channels), Label: files[i].Name}) }
+	}
+	pass1 := spans
+	bextAt := func(frame int64) *bextInfo {
+		// первым в bext части — файл, звучащий на её начале
+		i := sort.Search(len(pass1), func(i int) bool { return pass1[i].End/int64(channels) > frame })
+		if i == len(pass1) { i = len(pass1) - 1 }
+		t := start.Add(time.Duration(float64(frame) / float64(sampleRate) * float64(time.Second)))
+		return newOutputBext(t, sampleRate, files[i].Name, outPCM, len(files))
+	}
+	out, err := newSplitWriter(o.Out, outPCM, o.Dither, splitFrames, o.SplitBytes, markers, bextAt)
 	if err != nil { fatal(U, err) }
-	defer func() { bw.Flush(); outf.Close() }()
-
-	// PASS2: запись с фейдом
-	written := int64(0)
-	writePCM16 := func(vals []int16) {
-		if len(vals) == 0 { return }
-		if err := binary.Write(bw, binary.LittleEndian, vals); err != nil {
-			fatal(U, err)
-		}
-		written += int64(len(vals))
-	}
-	toPCM16Scaled := func(f []float32, start, end int) []int16 {
-		if start < 0 { start = 0 }
-		if end > len(f) { end = len(f) }
-		n := end - start
-		out := make([]int16, n)
-		for i := 0; i < n; i++ {
-			v := float64(f[start+i] * gain * scale)
-			if v > 1.0 { v = 1.0 }
-			if v < -1.0 { v = -1.0 }
-			out[i] = int16(math.Round(v * 32767.0))
+
+	// PASS2: запись с фейдом; gain и scale применяются к уже сшитому потоку, затем лимитер
+	g := gain * scale
+	var lim *limiter
+	if cfg.DoLimiter { lim = newLimiter(channels, sampleRate, cfg.LimiterCeiling, cfg.LimiterAttackMS, cfg.LimiterReleaseMS) }
+	var overs int64 // сэмплов, которые были бы обрезаны на ±1.0
+	work := make([]float32, streamFrames*channels)
+	write := func(p []float32) error {
+		if len(p) > len(work) { work = make([]float32, len(p)) }
+		w := work[:len(p)]
+		for i, v := range p {
+			w[i] = v * g
+			if w[i] > 1 || w[i] < -1 { overs++ }
 		}
-		return out
+		if lim != nil { w = lim.Process(w) }
+		return out.Write(w)
 	}
-	int16ToFloat32 := func(src []int16) []float32 {
-		out := make([]float32, len(src))
-		const s = 1.0 / 32768.0
-		for i := range src { out[i] = float32(src[i]) * float32(s) }
-		return out
+	spans, err = mergeFiles(files, sampleRate, channels, fadeTotal, o.Curve, tl, o.Repair, write,
+		func(done int) { U.PrintBar("PASS2 merge:", done, len(files)) })
+	U.EndBar()
+	if err == nil && lim != nil { err = out.Write(lim.Flush()) }
+	if err == nil { err = out.Close() }
+	if err != nil { out.Abort(); fatal(U, err) }
+	switch {
+	case lim != nil:
+		U.LogInfo("limiter: %d сэмплов были бы обрезаны, макс. подавление %.2f dB", overs, -toDB(lim.minGain))
+	case overs > 0 && outPCM.AudioFormat == wavFormatFloat:
+		U.LogInfo("float: %d сэмплов выше 0 dBFS (сохранены без клиппирования)", overs)
+	case overs > 0:
+		U.LogWarn("clipped: %d сэмплов обрезано на ±1.0 (см. --limiter-ceiling)", overs)
 	}
 
-	firstF := int16ToFloat32(first.Data)
-	if fadeTotal > 0 && len(firstF) >= fadeTotal {
-		writePCM16(toPCM16Scaled(firstF, 0, len(firstF)-fadeTotal))
-		prevTail = firstF[len(firstF)-fadeTotal:]
-		havePrev = true
-	} else {
-		writePCM16(toPCM16Scaled(firstF, 0, len(firstF)))
-		havePrev = false
-	}
-
-	U.PrintBar("PASS2 merge:", 1, len(files))
-	for i := 1; i < len(files); i++ {
-		w, _, err := readWav(files[i].Path)
-		if err != nil { fatal(U, fmt.Errorf("%s: %w", files[i].Path, err)) }
-		curF := int16ToFloat32(w.Data)
-
-		if fadeTotal > 0 && havePrev && len(curF) >= fadeTotal {
-			// смешанный фейд
-			mix := make([]float32, fadeTotal)
-			for k := 0; k < fadeTotal; k++ {
-				alpha := float64(k) / float64(fadeTotal)
-				mix[k] = float32((1.0-alpha)*float64(prevTail[k]) + alpha*float64(curF[k]))
-			}
-			writePCM16(toPCM16Scaled(mix, 0, len(mix)))
-
-			// середина
-			midStart := fadeTotal
-			midEnd := len(curF)
-			if i < len(files)-1 && len(curF) >= 2*fadeTotal {
-				midEnd = len(curF) - fadeTotal
-				prevTail = curF[len(curF)-fadeTotal:]
-				havePrev = true
-			} else {
-				havePrev = false
-			}
-			if midEnd > midStart {
-				writePCM16(toPCM16Scaled(curF, midStart, midEnd))
-			}
-
-			// хвост последнего файла
-			if i == len(files)-1 && len(curF) >= fadeTotal {
-				tail := curF[len(curF)-fadeTotal:]
-				writePCM16(toPCM16Scaled(tail, 0, len(tail)))
-			}
-		} else {
-			writePCM16(toPCM16Scaled(curF, 0, len(curF)))
-			havePrev = false
+	if out.dropped > 0 { U.LogWarn("markers: %d меток за пределом 2^32 кадров пропущено", out.dropped) }
+
+	// Индекс сегментов рядом с итогом (при разбиении — один на все части, по имени набора)
+	outPath := out.base
+	if o.WantJSON || o.WantCSV {
+		skipped := append(silence.Dropped[:len(silence.Dropped):len(silence.Dropped)], bad.files()...)
+		idx := buildIndex(outPath, files, spans, sampleRate, channels, start, skipped, out.parts)
+		jsonPath, csvPath := indexPaths(outPath)
+		if o.WantJSON {
+			if err := writeIndexJSON(jsonPath, idx); err != nil { fatal(U, err) }
+			U.LogOK("Index saved: %s", jsonPath)
+		}
+		if o.WantCSV {
+			if err := writeIndexCSV(csvPath, idx); err != nil { fatal(U, err) }
+			U.LogOK("Index saved: %s", csvPath)
 		}
-		U.PrintBar("PASS2 merge:", i+1, len(files))
 	}
-	U.EndBar()
 
-	if written != int64(totalSamples) {
-		U.LogWarn("written samples=%d, planned=%d (OK при очень коротких файлах/фейде)", written, totalSamples)
+	if out.Written() != totalSamples {
+		U.LogWarn("written samples=%d, planned=%d", out.Written(), totalSamples)
+	}
+	if !out.split {
+		U.LogOK("Output saved: %s", outPath)
+		res.Outputs = []string{outPath}
+		return res, nil
+	}
+	for _, pt := range out.parts {
+		U.LogOK("Part saved: %s (%.3f s)", pt.Path, float64(pt.End-pt.Start)/float64(sampleRate))
+		res.Outputs = append(res.Outputs, pt.Path)
 	}
-	U.LogOK("Output saved: %s", outPath)
+	return res, nil
 }
 
-// ---------- утилиты и WAV I/O ----------
+// ---------- утилиты ----------
 
 func fatal(U ui.UIAPI, err error) { U.LogErr("%v", err); os.Exit(1) }
 
-func collectWavsRecursive(dir string) ([]fileInfo, error) {
-	var out []fileInfo
-	walkFn := func(path string, d os.DirEntry, err error) error {
-		if err != nil { return err }
-		if d.IsDir() { return nil }
-		name := d.Name()
-		if !strings.HasSuffix(strings.ToLower(name), ".wav") { return nil }
-		fi, err := d.Info()
-		if err != nil { return err }
-		out = append(out, fileInfo{Name: name, Path: path, ModTime: fi.ModTime()})
-		return nil
-	}
-	if err := filepath.WalkDir(dir, walkFn); err != nil { return nil, err }
+// fileStart — время начала записи файла. src=auto: имя по шаблону → bext → mtime минус длительность;
+// name/bext — только этот источник (при неудаче — mtime); mtime — всегда mtime.
+func fileStart(fi fileInfo, h wavHeader, src string, np *nameTimeParser) (time.Time, string) {
+	if np != nil && (src == "auto" || src == "name") {
+		if t, ok := np.Parse(fi.Path); ok { return t, "name" }
+	}
+	if h.Bext != nil && (src == "auto" || src == "bext") {
+		if t, ok := h.Bext.Start(int(h.PCM.SampleRate)); ok { return t, "bext" }
+	}
+	dur := time.Duration(float64(h.Frames) / float64(h.PCM.SampleRate) * float64(time.Second))
+	return fi.ModTime.Add(-dur), "mtime"
+}
+
+// outputFormat — формат итога: PCM --out-bits или float; раскладка каналов (и значащие биты
+// при той же разрядности) берутся у эталона ref.
+func outputFormat(cfg *Config, ref wavPCM, rate int) (wavPCM, error) {
+	outFmt, outBits := uint16(wavFormatPCM), cfg.OutBits
+	switch {
+	case cfg.OutFloat || (cfg.OutBits == 0 && ref.AudioFormat == wavFormatFloat):
+		outFmt, outBits = wavFormatFloat, 32
+	case cfg.OutBits == 0:
+		outBits = int(ref.BitsPerSample)
+	}
+	if outFmt == wavFormatPCM && !supportedPCMBits(outBits) {
+		return wavPCM{}, fmt.Errorf("некорректный --out-bits: %d (8|16|24|32|0)", cfg.OutBits)
+	}
+	out := pcmFormat(outFmt, uint32(rate), ref.NumChannels, uint16(outBits))
+	out.ChannelMask = ref.ChannelMask
+	if outFmt == ref.AudioFormat && out.BitsPerSample == ref.BitsPerSample { out.ValidBits = ref.ValidBits }
 	return out, nil
 }
 
+func orDash(s string) string {
+	if s == "" { return "…" }
+	return s
+}
+
+func wallOrDash(t time.Time) string {
+	if t.IsZero() { return "…" }
+	return t.Format("2006-01-02 15:04:05.000")
+}
+
 func ensureDir(dir string) error {
 	if dir == "" { return nil }
 	return os.MkdirAll(dir, 0755)
@@ -311,135 +671,10 @@ func nextAvailablePath(p string) (string, error) {
 	return "", fmt.Errorf("не удалось подобрать свободное имя для %s", p)
 }
 
-func createWavWriter(path string, sampleRate uint32, channels uint16, totalSamples uint32) (*os.File, *bufio.Writer, error) {
-	f, err := os.Create(path)
-	if err != nil { return nil, nil, err }
-	bw := bufio.NewWriter(f)
-
-	dataSize := totalSamples * 2 // PCM16: 2 байта на сэмпл
-	fmtSize := uint32(16)
-	riffSize := uint32(4 + (8 + fmtSize) + (8 + dataSize))
-
-	// RIFF/WAVE
-	if _, err := bw.WriteString("RIFF"); err != nil { f.Close(); return nil, nil, err }
-	if err := binary.Write(bw, binary.LittleEndian, riffSize); err != nil { f.Close(); return nil, nil, err }
-	if _, err := bw.WriteString("WAVE"); err != nil { f.Close(); return nil, nil, err }
-
-	// fmt
-	if _, err := bw.WriteString("fmt "); err != nil { f.Close(); return nil, nil, err }
-	if err := binary.Write(bw, binary.LittleEndian, fmtSize); err != nil { f.Close(); return nil, nil, err }
-	var audioFormat uint16 = 1 // PCM
-	byteRate := sampleRate * uint32(channels) * 2
-	blockAlign := channels * 2
-	var bitsPerSample uint16 = 16
-	if err := binary.Write(bw, binary.LittleEndian, audioFormat); err != nil { f.Close(); return nil, nil, err }
-	if err := binary.Write(bw, binary.LittleEndian, channels); err != nil { f.Close(); return nil, nil, err }
-	if err := binary.Write(bw, binary.LittleEndian, sampleRate); err != nil { f.Close(); return nil, nil, err }
-	if err := binary.Write(bw, binary.LittleEndian, byteRate); err != nil { f.Close(); return nil, nil, err }
-	if err := binary.Write(bw, binary.LittleEndian, blockAlign); err != nil { f.Close(); return nil, nil, err }
-	if err := binary.Write(bw, binary.LittleEndian, bitsPerSample); err != nil { f.Close(); return nil, nil, err }
-
-	// data
-	if _, err := bw.WriteString("data"); err != nil { f.Close(); return nil, nil, err }
-	if err := binary.Write(bw, binary.LittleEndian, dataSize); err != nil { f.Close(); return nil, nil, err }
-	return f, bw, nil
-}
-
-func readWav(path string) (wavData, wavPCM, error) {
-	f, err := os.Open(path)
-	if err != nil { return wavData{}, wavPCM{}, err }
-	defer f.Close()
-
-	br := bufio.NewReader(f)
-
-	// RIFF
-	var riff [4]byte
-	if _, err := io.ReadFull(br, riff[:]); err != nil { return wavData{}, wavPCM{}, err }
-	if string(riff[:]) != "RIFF" { return wavData{}, wavPCM{}, errors.New("не RIFF") }
-	// Skip ChunkSize
-	if _, err := br.Discard(4); err != nil { return wavData{}, wavPCM{}, err }
-	var wave [4]byte
-	if _, err := io.ReadFull(br, wave[:]); err != nil { return wavData{}, wavPCM{}, err }
-	if string(wave[:]) != "WAVE" { return wavData{}, wavPCM{}, errors.New("не WAVE") }
-
-	var pcm wavPCM
-	var data []int16
-
-	for {
-		var id [4]byte
-		if _, err := io.ReadFull(br, id[:]); err != nil {
-			if errors.Is(err, io.EOF) { break }
-			return wavData{}, wavPCM{}, err
-		}
-		var size uint32
-		if err := binary.Read(br, binary.LittleEndian, &size); err != nil { return wavData{}, wavPCM{}, err }
-
-		switch string(id[:]) {
-		case "fmt ":
-			buf := make([]byte, size)
-			if _, err := io.ReadFull(br, buf); err != nil { return wavData{}, wavPCM{}, err }
-			b := bytes.NewReader(buf)
-			if err := binary.Read(b, binary.LittleEndian, &pcm.AudioFormat); err != nil { return wavData{}, wavPCM{}, err }
-			if err := binary.Read(b, binary.LittleEndian, &pcm.NumChannels); err != nil { return wavData{}, wavPCM{}, err }
-			if err := binary.Read(b, binary.LittleEndian, &pcm.SampleRate); err != nil { return wavData{}, wavPCM{}, err }
-			if err := binary.Read(b, binary.LittleEndian, &pcm.ByteRate); err != nil { return wavData{}, wavPCM{}, err }
-			if err := binary.Read(b, binary.LittleEndian, &pcm.BlockAlign); err != nil { return wavData{}, wavPCM{}, err }
-			if err := binary.Read(b, binary.LittleEndian, &pcm.BitsPerSample); err != nil { return wavData{}, wavPCM{}, err }
-		case "data":
-			if pcm.AudioFormat == 0 { return wavData{}, wavPCM{}, errors.New("встретили data до fmt") }
-			if pcm.BitsPerSample != 16 { return wavData{}, wavPCM{}, fmt.Errorf("поддерживается только 16 бит, найдено: %d", pcm.BitsPerSample) }
-			frames := int(size / 2)
-			data = make([]int16, frames)
-			if err := binary.Read(br, binary.LittleEndian, &data); err != nil { return wavData{}, wavPCM{}, err }
-		default:
-			if _, err := br.Discard(int(size)); err != nil { return wavData{}, wavPCM{}, err }
-		}
-		// выравнивание
-		if size%2 == 1 {
-			if _, err := br.Discard(1); err != nil { return wavData{}, wavPCM{}, err }
-		}
-	}
-
-	if len(data) == 0 { return wavData{}, wavPCM{}, errors.New("нет аудио-данных (data chunk)") }
-	return wavData{PCM: pcm, Data: data}, pcm, nil
-}
-
 // DSP утилиты
-func updatePeakWhole(peak *float64, pcm16 []int16, gain float32) {
-	const s = 1.0 / 32768.0
-	for _, v := range pcm16 {
-		f := float64(float32(v) * float32(s) * gain)
-		av := math.Abs(f)
-		if av > *peak { *peak = av }
-	}
-}
-func updatePeakCrossfade(peak *float64, prevTail, curHead []float32) {
-	n := len(prevTail)
-	if len(curHead) < n { n = len(curHead) }
-	if n == 0 { return }
-	for i := 0; i < n; i++ {
-		alpha := float64(i) / float64(n)
-		mix := (1.0-alpha)*float64(prevTail[i]) + alpha*float64(curHead[i])
-		av := math.Abs(mix)
+func updatePeak(peak *float64, f []float32, gain float32) {
+	for _, v := range f {
+		av := math.Abs(float64(v * gain))
 		if av > *peak { *peak = av }
 	}
 }
-func takeTailAsFloat(pcm16 []int16, gain float32, fadeTotal int) []float32 {
-	if fadeTotal <= 0 || len(pcm16) < fadeTotal { return nil }
-	const s = 1.0 / 32768.0
-	out := make([]float32, fadeTotal)
-	start := len(pcm16) - fadeTotal
-	for i := 0; i < fadeTotal; i++ {
-		out[i] = float32(pcm16[start+i]) * float32(s) * gain
-	}
-	return out
-}
-func takeHeadAsFloat(pcm16 []int16, gain float32, fadeTotal int) []float32 {
-	if fadeTotal <= 0 || len(pcm16) < fadeTotal { return nil }
-	const s = 1.0 / 32768.0
-	out := make([]float32, fadeTotal)
-	for i := 0; i < fadeTotal; i++ {
-		out[i] = float32(pcm16[i]) * float32(s) * gain
-	}
-	return out
-}
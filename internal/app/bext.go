@@ -0,0 +1,97 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\bext.go
+// Package: app
+// Назначение: Broadcast WAV (EBU Tech 3285): разбор и формирование чанка bext, время начала записи.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const bextFixedSize = 602 // фиксированная часть bext (до CodingHistory)
+
+type bextInfo struct {
+	Description     string
+	Originator      string
+	OriginatorRef   string
+	OriginationDate string // yyyy-mm-dd
+	OriginationTime string // hh:mm:ss
+	TimeReference   uint64 // сэмплов от полуночи
+	Version         uint16
+	CodingHistory   string
+}
+
+func parseBext(buf []byte) (*bextInfo, error) {
+	if len(buf) < 348 { return nil, errors.New("bext: короткий чанк") }
+	str := func(b []byte) string { return strings.TrimRight(string(b), "\x00 ") }
+	b := &bextInfo{
+		Description:     str(buf[0:256]),
+		Originator:      str(buf[256:288]),
+		OriginatorRef:   str(buf[288:320]),
+		OriginationDate: str(buf[320:330]),
+		OriginationTime: str(buf[330:338]),
+		TimeReference:   binary.LittleEndian.Uint64(buf[338:346]),
+		Version:         binary.LittleEndian.Uint16(buf[346:348]),
+	}
+	if len(buf) > bextFixedSize { b.CodingHistory = str(buf[bextFixedSize:]) }
+	return b, nil
+}
+
+// bextChunk — тело bext (версия 1, без данных громкости).
+func bextChunk(b *bextInfo) []byte {
+	out := make([]byte, bextFixedSize, bextFixedSize+len(b.CodingHistory)+1)
+	put := func(off, n int, s string) { copy(out[off:off+n], s) }
+	put(0, 256, b.Description)
+	put(256, 32, b.Originator)
+	put(288, 32, b.OriginatorRef)
+	put(320, 10, b.OriginationDate)
+	put(330, 8, b.OriginationTime)
+	binary.LittleEndian.PutUint64(out[338:], b.TimeReference)
+	binary.LittleEndian.PutUint16(out[346:], 1)
+	out = append(out, b.CodingHistory...)
+	if len(out)%2 == 1 { out = append(out, 0) }
+	return out
+}
+
+// Start — момент начала записи: дата + TimeReference (точность до сэмпла), иначе дата + время.
+func (b *bextInfo) Start(sampleRate int) (time.Time, bool) {
+	day, err := time.ParseInLocation("2006-01-02", strings.NewReplacer(":", "-", ".", "-", "/", "-").Replace(b.OriginationDate), time.Local)
+	if err != nil { return time.Time{}, false }
+	if b.TimeReference > 0 && sampleRate > 0 {
+		return day.Add(time.Duration(float64(b.TimeReference) / float64(sampleRate) * float64(time.Second))), true
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", day.Format("2006-01-02")+" "+strings.NewReplacer("-", ":", ".", ":").Replace(b.OriginationTime), time.Local)
+	if err != nil { return day, true }
+	return t, true
+}
+
+// newOutputBext — bext итогового файла: время начала и TimeReference соответствуют первому файлу.
+func newOutputBext(start time.Time, sampleRate int, firstName string, pcm wavPCM, files int) *bextInfo {
+	midnight := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	mode := "mono"
+	if pcm.NumChannels == 2 { mode = "stereo" }
+	if pcm.NumChannels > 2 { mode = fmt.Sprintf("%dch", pcm.NumChannels) }
+	coding := "PCM"
+	if pcm.AudioFormat == wavFormatFloat { coding = "FLOAT" }
+	return &bextInfo{
+		Description:     fmt.Sprintf("AcousticMerge: %d files merged", files),
+		Originator:      "AcousticMerge",
+		OriginatorRef:   truncate(firstName, 32),
+		OriginationDate: start.Format("2006-01-02"),
+		OriginationTime: start.Format("15:04:05"),
+		TimeReference:   uint64(start.Sub(midnight).Seconds()*float64(sampleRate) + 0.5),
+		CodingHistory:   fmt.Sprintf("A=%s,F=%d,W=%d,M=%s,T=AcousticMerge\r\n", coding, sampleRate, pcm.BitsPerSample, mode),
+	}
+}
+
+// truncate — не длиннее n байт; режет по границе символа, чтобы кириллица в имени не ломала UTF-8.
+func truncate(s string, n int) string {
+	if len(s) <= n { return s }
+	for n > 0 && !utf8.RuneStart(s[n]) { n-- }
+	return s[:n]
+}
@@ -0,0 +1,28 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\bext_test.go
+// Package: app
+// Назначение: Тесты bext: поле OriginatorRef не рвёт многобайтные символы.
+
+import (
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestOutputBextOriginatorRef(t *testing.T) {
+	for _, name := range []string{
+		"short.wav",
+		"rec1_запись_утро_микрофон.wav", // кириллица: 2 байта на символ, предел 32 байта — посреди символа
+		"rec_🎙️_2025-01-01_12-00-00-000.wav",
+	} {
+		b := newOutputBext(time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local), 48000, name, pcmFormat(wavFormatPCM, 48000, 2, 16), 1)
+		if len(b.OriginatorRef) > 32 || !utf8.ValidString(b.OriginatorRef) {
+			t.Fatalf("%q → %q (%d байт): ожидалось ≤32 байт и корректный UTF-8", name, b.OriginatorRef, len(b.OriginatorRef))
+		}
+		// после записи и разбора чанка значение то же
+		p, err := parseBext(bextChunk(b))
+		if err != nil { t.Fatal(err) }
+		if p.OriginatorRef != b.OriginatorRef { t.Fatalf("после разбора %q, ожидалось %q", p.OriginatorRef, b.OriginatorRef) }
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\collect.go
+// Package: app
+// Назначение: Сбор WAV-файлов: рекурсивный обход, include/exclude-маски, ограничение глубины, симлинки.
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type collectOpts struct {
+	Include        []string // маски файлов (любая); пусто — все .wav
+	Exclude        []string // маски файлов и папок (папки не обходятся)
+	MaxDepth       int      // уровней вложенности под src; <0 — без ограничения
+	FollowSymlinks bool     // заходить в папки по ссылкам (файлы-ссылки берутся всегда)
+}
+
+// globSet — набор масок. Маска без "/" сравнивается с именем (файла или папки),
+// с "/" — с путём относительно src; "**" — любое число уровней. Регистр не учитывается.
+type globSet []*regexp.Regexp
+
+func compileGlobs(patterns []string) (globSet, error) {
+	var gs globSet
+	for _, p := range patterns {
+		re, err := regexp.Compile(globToRegexp(p))
+		if err != nil { return nil, fmt.Errorf("маска %q: %w", p, err) }
+		gs = append(gs, re)
+	}
+	return gs, nil
+}
+
+func globToRegexp(p string) string {
+	p = filepath.ToSlash(p)
+	var sb strings.Builder
+	sb.WriteString("(?i)^")
+	if !strings.Contains(p, "/") { sb.WriteString("(?:.*/)?") } // имя на любом уровне
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			// класс символов как в filepath.Match: [!…] — отрицание, "/" не входит ни в какой класс
+			if j := strings.IndexByte(p[i:], ']'); j > 0 {
+				cls := p[i+1 : i+j]
+				if strings.HasPrefix(cls, "!") || strings.HasPrefix(cls, "^") {
+					sb.WriteString("[^/" + cls[1:] + "]")
+				} else {
+					sb.WriteString("[" + cls + "]")
+				}
+				i += j
+			} else {
+				sb.WriteString(`\[`)
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+func (gs globSet) match(rel string) bool {
+	for _, re := range gs {
+		if re.MatchString(rel) { return true }
+	}
+	return false
+}
+
+func collectWavsRecursive(dir string, opt collectOpts) ([]fileInfo, error) {
+	inc, err := compileGlobs(opt.Include)
+	if err != nil { return nil, err }
+	exc, err := compileGlobs(opt.Exclude)
+	if err != nil { return nil, err }
+
+	var out []fileInfo
+	visited := map[string]bool{} // реальные пути папок — защита от циклов по ссылкам
+	var walk func(path, rel string, depth int) error
+	walk = func(path, rel string, depth int) error {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			if visited[real] { return nil }
+			visited[real] = true
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil { return err }
+		for _, d := range entries {
+			name := d.Name()
+			p := filepath.Join(path, name)
+			r := name
+			if rel != "" { r = rel + "/" + name }
+
+			isDir := d.IsDir()
+			var info fs.FileInfo
+			if d.Type()&os.ModeSymlink != 0 {
+				// как в прежнем обходе: файл-ссылка — обычный вход (битая всплывёт ошибкой чтения),
+				// папка-ссылка — только с FollowSymlinks
+				st, err := os.Stat(p)
+				switch {
+				case err != nil:
+				case st.IsDir():
+					if !opt.FollowSymlinks { continue }
+					isDir = true
+				case opt.FollowSymlinks:
+					info = st
+				}
+			}
+			if exc.match(r) { continue }
+			if isDir {
+				if opt.MaxDepth >= 0 && depth >= opt.MaxDepth { continue }
+				if err := walk(p, r, depth+1); err != nil { return err }
+				continue
+			}
+			if !strings.HasSuffix(strings.ToLower(name), ".wav") { continue }
+			if len(inc) > 0 && !inc.match(r) { continue }
+			if info == nil {
+				if info, err = d.Info(); err != nil { return err }
+			}
+			out = append(out, fileInfo{Name: name, Path: p, ModTime: info.ModTime()})
+		}
+		return nil
+	}
+	if err := walk(dir, "", 0); err != nil { return nil, err }
+	return out, nil
+}
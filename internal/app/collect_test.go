@@ -0,0 +1,93 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\collect_test.go
+// Package: app
+// Назначение: Тесты сбора файлов: маски include/exclude, глубина обхода, символические ссылки.
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	cases := []struct {
+		glob, rel string
+		want      bool
+	}{
+		{"*.wav", "a.wav", true},
+		{"*.wav", "day/a.wav", true}, // маска без "/" — имя на любом уровне
+		{"*.wav", "A.WAV", true},
+		{"2025*.wav", "x/2025_01.wav", true},
+		{"2025*.wav", "x/2024_01.wav", false},
+		{"_rejected", "day/_rejected", true},
+		{"**/_preview/**", "a/_preview/b.wav", true},
+		{"**/_preview/**", "_preview/b.wav", true},
+		{"day/*.wav", "day/a.wav", true},
+		{"day/*.wav", "day/sub/a.wav", false},
+		{"day/*.wav", "x/day/a.wav", false}, // маска с "/" — от корня --src
+		{"day/**/*.wav", "day/a/b/c.wav", true},
+		{"seg_?.wav", "seg_1.wav", true},
+		{"seg_?.wav", "seg_10.wav", false},
+		{"seg_[0-4].wav", "seg_3.wav", true},
+		{"seg_[0-4].wav", "seg_5.wav", false},
+		{"seg_[!0-4].wav", "seg_5.wav", true},
+		{"seg_[!0-4].wav", "seg_3.wav", false},
+		{"seg_[!a].wav", "seg_a.wav", false}, // не «! или a»
+		{"seg_[!a].wav", "seg_b.wav", true},
+		{"seg_[^0-4].wav", "seg_5.wav", true},
+		{"d/a[!b]c", "d/a/c", false}, // класс не совпадает с "/"
+		{"a.b", "axb", false},
+		{"[abc", "[abc", true},
+	}
+	for _, tc := range cases {
+		re := regexp.MustCompile(globToRegexp(tc.glob))
+		if got := re.MatchString(tc.rel); got != tc.want {
+			t.Fatalf("%q ~ %q = %v, ожидалось %v (%s)", tc.glob, tc.rel, got, tc.want, re)
+		}
+	}
+}
+
+func TestCollectWavs(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	for _, p := range []string{"a.wav", "b.txt", "day_1/c.WAV", "day_1/_rejected/d.wav", "day_1/deep/e.wav", "outside/f.wav"} {
+		dir := src
+		if p == "outside/f.wav" { dir = root }
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil { t.Fatal(err) }
+		if err := os.WriteFile(full, nil, 0644); err != nil { t.Fatal(err) }
+	}
+	symlinks := os.Symlink(filepath.Join(src, "a.wav"), filepath.Join(src, "link.wav")) == nil &&
+		os.Symlink(filepath.Join(root, "outside"), filepath.Join(src, "linkdir")) == nil
+
+	names := func(opt collectOpts) []string {
+		files, err := collectWavsRecursive(src, opt)
+		if err != nil { t.Fatal(err) }
+		var out []string
+		for _, f := range files {
+			rel, _ := filepath.Rel(src, f.Path)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		sort.Strings(out)
+		return out
+	}
+	check := func(name string, opt collectOpts, want ...string) {
+		t.Helper()
+		if got := names(opt); !reflect.DeepEqual(got, want) { t.Fatalf("%s: %q, ожидалось %q", name, got, want) }
+	}
+	if !symlinks {
+		check("all", collectOpts{MaxDepth: -1}, "a.wav", "day_1/_rejected/d.wav", "day_1/c.WAV", "day_1/deep/e.wav")
+		t.Skip("символические ссылки недоступны")
+	}
+	// файл-ссылка берётся всегда, папка-ссылка — только с FollowSymlinks
+	check("all", collectOpts{MaxDepth: -1}, "a.wav", "day_1/_rejected/d.wav", "day_1/c.WAV", "day_1/deep/e.wav", "link.wav")
+	check("follow", collectOpts{MaxDepth: -1, FollowSymlinks: true}, "a.wav", "day_1/_rejected/d.wav", "day_1/c.WAV", "day_1/deep/e.wav", "link.wav", "linkdir/f.wav")
+	check("exclude", collectOpts{MaxDepth: -1, Exclude: []string{"_rejected", "link*"}}, "a.wav", "day_1/c.WAV", "day_1/deep/e.wav")
+	check("include", collectOpts{MaxDepth: -1, Include: []string{"day_1/**"}}, "day_1/_rejected/d.wav", "day_1/c.WAV", "day_1/deep/e.wav")
+	check("depth0", collectOpts{MaxDepth: 0}, "a.wav", "link.wav")
+	check("depth1", collectOpts{MaxDepth: 1}, "a.wav", "day_1/c.WAV", "link.wav")
+}
@@ -0,0 +1,100 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\group.go
+// Package: app
+// Назначение: Режим групп (--group-by dir|day|hour): разбиение файлов на группы, имена итогов, сводная таблица.
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"acousticmerge/internal/ui"
+)
+
+// fileGroup — файлы одного итога в порядке общей сортировки (и их тайминги из --list).
+type fileGroup struct {
+	Key     string
+	Files   []fileInfo
+	Timings []listTiming
+}
+
+// groupFiles раскладывает файлы по ключу: dir — папка относительно src (корень — имя src),
+// day/hour — дата (час) начала записи, время — как в fileStart. Группы — по возрастанию ключа.
+func groupFiles(files []fileInfo, timings []listTiming, by, src, tsrc string, np *nameTimeParser, repair bool) ([]fileGroup, error) {
+	byKey := map[string]*fileGroup{}
+	var keys []string
+	for i, fi := range files {
+		var key string
+		switch by {
+		case "dir":
+			dir := filepath.Dir(fi.Path)
+			if rel, err := filepath.Rel(src, dir); err == nil && !strings.HasPrefix(rel, "..") { dir = rel }
+			if dir == "." { dir = filepath.Base(filepath.Clean(src)) }
+			key = filepath.ToSlash(dir)
+		case "day", "hour":
+			h, err := probeWavHeader(fi.Path, repair)
+			if err != nil { return nil, fmt.Errorf("%s: %w", fi.Path, err) }
+			t, _ := fileStart(fi, h, tsrc, np)
+			key = t.Format("2006-01-02")
+			if by == "hour" { key = t.Format("2006-01-02_15") }
+		}
+		g := byKey[key]
+		if g == nil {
+			g = &fileGroup{Key: key}
+			byKey[key] = g
+			keys = append(keys, key)
+		}
+		g.Files = append(g.Files, fi)
+		if timings != nil { g.Timings = append(g.Timings, timings[i]) }
+	}
+	sort.Slice(keys, func(i, j int) bool { return naturalCompare(keys[i], keys[j]) < 0 })
+	groups := make([]fileGroup, 0, len(keys))
+	for _, k := range keys { groups = append(groups, *byKey[k]) }
+	return groups, nil
+}
+
+// groupOutPath — merged.wav + "2025-01-01/13" → merged_2025-01-01_13.wav
+func groupOutPath(out, key string) string {
+	ext := filepath.Ext(out)
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		}
+		return r
+	}, key)
+	return strings.TrimSuffix(out, ext) + "_" + name + ext
+}
+
+// printGroupTable — сводка в конце: группа, файлов, длительность, итог(и); последняя строка — «Всего».
+func printGroupTable(U ui.UIAPI, groups []fileGroup, results []groupResult) {
+	rows := make([][]string, 0, len(groups)+1)
+	var files int
+	var dur float64
+	for i, g := range groups {
+		r := results[i]
+		out := strings.Join(r.Outputs, ", ")
+		switch {
+		case r.Files == 0:
+			out = fmt.Sprintf("пропущена (%d файлов на входе)", len(g.Files))
+		case out == "":
+			out = "—" // dry-run
+		}
+		if r.Skipped > 0 { out += fmt.Sprintf(" (пропущено повреждённых: %d)", r.Skipped) }
+		rows = append(rows, []string{g.Key, strconv.Itoa(r.Files), formatHMS(r.Duration), out})
+		files += r.Files
+		dur += r.Duration
+	}
+	rows = append(rows, []string{"Всего", strconv.Itoa(files), formatHMS(dur), ""})
+	fmt.Println()
+	U.PrintTable("lrrl", []string{"Группа", "Файлов", "Длительность", "Итог"}, rows)
+}
+
+// formatHMS — секунды → чч:мм:сс.ммм
+func formatHMS(sec float64) string {
+	ms := int64(sec*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
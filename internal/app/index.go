@@ -0,0 +1,150 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\index.go
+// Package: app
+// Назначение: Индекс сегментов (JSON/CSV рядом с итогом): время в итоговом файле → исходный файл.
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type indexEntry struct {
+	Index       int     `json:"index"`
+	Path        string  `json:"path"`
+	ModTime     string  `json:"mtime"`
+	StartSample int64   `json:"start_sample"` // кадр в итоговом файле (включительно)
+	EndSample   int64   `json:"end_sample"`   // кадр в итоговом файле (исключительно)
+	StartSec    float64 `json:"start_sec"`
+	EndSec      float64 `json:"end_sec"`
+	Peak        float64 `json:"peak"`      // пик исходного файла (линейный, до gain)
+	PeakDBFS    float64 `json:"peak_dbfs"` // тот же пик в дБFS
+	TrimInSec   float64 `json:"trim_in_sec"`  // срезано в начале исходного файла
+	TrimOutSec  float64 `json:"trim_out_sec"` // срезано в конце
+	GapSec      float64 `json:"gap_sec"`      // тишина перед файлом (заполнение паузы)
+	Part        int     `json:"part,omitempty"` // номер части, где начинается файл (--split-*)
+}
+
+// indexPart — часть итога при разбиении; кадры — сквозные, как у файлов.
+type indexPart struct {
+	Path        string  `json:"path"`
+	StartSample int64   `json:"start_sample"`
+	EndSample   int64   `json:"end_sample"`
+	StartSec    float64 `json:"start_sec"`
+	EndSec      float64 `json:"end_sec"`
+}
+
+type indexFile struct {
+	Output     string       `json:"output"`
+	SampleRate int          `json:"sample_rate"`
+	Channels   int          `json:"channels"`
+	Start      string       `json:"start_time"`
+	Parts      []indexPart  `json:"parts,omitempty"`
+	Files      []indexEntry `json:"files"`
+	Skipped    []string     `json:"skipped,omitempty"` // файлы, не попавшие в итог
+}
+
+func buildIndex(outPath string, files []fileInfo, spans []segSpan, sampleRate, channels int, start time.Time, skipped []fileInfo, parts []partInfo) indexFile {
+	idx := indexFile{
+		Output:     outPath,
+		SampleRate: sampleRate,
+		Channels:   channels,
+		Start:      start.Format(time.RFC3339Nano),
+		Files:      make([]indexEntry, 0, len(spans)),
+	}
+	for _, fi := range skipped { idx.Skipped = append(idx.Skipped, fi.Path) }
+	if len(parts) > 1 {
+		for _, pt := range parts {
+			idx.Parts = append(idx.Parts, indexPart{
+				Path:        pt.Path,
+				StartSample: pt.Start,
+				EndSample:   pt.End,
+				StartSec:    float64(pt.Start) / float64(sampleRate),
+				EndSec:      float64(pt.End) / float64(sampleRate),
+			})
+		}
+	}
+	part := 0
+	for i, sp := range spans {
+		startF, endF := sp.Start/int64(channels), sp.End/int64(channels)
+		for idx.Parts != nil && part < len(parts)-1 && startF >= parts[part+1].Start { part++ }
+		idx.Files = append(idx.Files, indexEntry{
+			Index:       i + 1,
+			Path:        files[i].Path,
+			ModTime:     files[i].ModTime.Format(time.RFC3339Nano),
+			StartSample: startF,
+			EndSample:   endF,
+			StartSec:    float64(startF) / float64(sampleRate),
+			EndSec:      float64(endF) / float64(sampleRate),
+			Peak:        sp.Peak,
+			PeakDBFS:    toDB(sp.Peak),
+			TrimInSec:   float64(files[i].TrimIn) / float64(sampleRate),
+			TrimOutSec:  float64(files[i].TrimOut) / float64(sampleRate),
+			GapSec:      float64(sp.Gap/int64(channels)) / float64(sampleRate),
+		})
+		if idx.Parts != nil { idx.Files[i].Part = part + 1 }
+	}
+	return idx
+}
+
+// indexPaths — merged.wav → merged.index.json / merged.index.csv
+func indexPaths(outPath string) (jsonPath, csvPath string) {
+	base := strings.TrimSuffix(outPath, filepath.Ext(outPath))
+	return base + ".index.json", base + ".index.csv"
+}
+
+func writeIndexJSON(path string, idx indexFile) error {
+	f, err := os.Create(path)
+	if err != nil { return err }
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(idx); err != nil { f.Close(); return err }
+	return f.Close()
+}
+
+func writeIndexCSV(path string, idx indexFile) error {
+	f, err := os.Create(path)
+	if err != nil { return err }
+	w := csv.NewWriter(f)
+	w.Write([]string{"index", "path", "mtime", "start_sample", "end_sample", "start_sec", "end_sec", "peak", "peak_dbfs", "trim_in_sec", "trim_out_sec", "gap_sec", "part"})
+	ff := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, e := range idx.Files {
+		w.Write([]string{
+			strconv.Itoa(e.Index), e.Path, e.ModTime,
+			strconv.FormatInt(e.StartSample, 10), strconv.FormatInt(e.EndSample, 10),
+			ff(e.StartSec), ff(e.EndSec), ff(e.Peak), ff(e.PeakDBFS),
+			ff(e.TrimInSec), ff(e.TrimOutSec), ff(e.GapSec), strconv.Itoa(e.Part),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil { f.Close(); return err }
+	return f.Close()
+}
+
+// toDB — линейная амплитуда → дБFS (тишина = -200 дБ, чтобы JSON оставался валидным).
+func toDB(v float64) float64 {
+	if v <= 1e-10 { return -200 }
+	return math.Round(20*math.Log10(v)*100) / 100
+}
+
+func indexFormats(s string) (wantJSON, wantCSV bool, err error) {
+	switch strings.ToLower(s) {
+	case "none", "":
+	case "json":
+		wantJSON = true
+	case "csv":
+		wantCSV = true
+	case "both":
+		wantJSON, wantCSV = true, true
+	default:
+		err = fmt.Errorf("неизвестный --index: %s (none|json|csv|both)", s)
+	}
+	return
+}
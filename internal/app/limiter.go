@@ -0,0 +1,100 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\limiter.go
+// Package: app
+// Назначение: Look-ahead brickwall-лимитер для PASS2 (вместо жёсткого клиппирования на ±1.0).
+
+import "math"
+
+// limiter — огибающая усиления строится по «будущим» сэмплам: минимум требуемого усиления
+// в окне attack сглаживается скользящим средним той же длины, а звук задерживается на окно.
+// Так усиление плавно опускается до пика и гарантированно не пропускает его выше потолка.
+// Длина потока сохраняется: задержка компенсируется — первые кадры не выдаются, в Flush дописываются.
+type limiter struct {
+	ch      int
+	ceiling float64
+	look    int       // окно look-ahead, кадров
+	relCoef float64   // коэффициент восстановления (release) за кадр
+	delay   []float32 // кольцо задержанных кадров (look × ch)
+	req     []float64 // кольцо требуемого усиления (look)
+	hold    []float64 // кольцо скользящего минимума (look) для бокс-фильтра
+	boxSum  float64
+	pos     int
+	fed     int64 // кадров принято
+	env     float64
+	minGain float64 // наибольшее подавление за прогон
+	out     []float32
+}
+
+func newLimiter(channels, rate int, ceilingDB, attackMS, releaseMS float64) *limiter {
+	look := int(math.Round(float64(rate) * attackMS / 1000))
+	if look < 2 { look = 2 }
+	l := &limiter{
+		ch:      channels,
+		ceiling: math.Pow(10, ceilingDB/20),
+		look:    look,
+		relCoef: 1,
+		delay:   make([]float32, look*channels),
+		req:     make([]float64, look),
+		hold:    make([]float64, look),
+		boxSum:  float64(look),
+		env:     1,
+		minGain: 1,
+	}
+	for i := range l.req { l.req[i], l.hold[i] = 1, 1 }
+	if releaseMS > 0 { l.relCoef = 1 - math.Exp(-1000/(releaseMS*float64(rate))) }
+	return l
+}
+
+// Process пропускает интерлив-сэмплы через лимитер; возвращает готовые кадры (буфер переиспользуется).
+func (l *limiter) Process(p []float32) []float32 {
+	l.out = l.out[:0]
+	for i := 0; i+l.ch <= len(p); i += l.ch { l.frame(p[i : i+l.ch]) }
+	return l.out
+}
+
+// Flush выдаёт задержанные кадры в конце потока.
+func (l *limiter) Flush() []float32 {
+	l.out = l.out[:0]
+	zero := make([]float32, l.ch)
+	for n := l.look - 1; n > 0 && l.fed > 0; n-- { l.frame(zero) }
+	return l.out
+}
+
+func (l *limiter) frame(in []float32) {
+	pk := 0.0
+	for _, v := range in {
+		if a := math.Abs(float64(v)); a > pk { pk = a }
+	}
+	r := 1.0
+	if pk > l.ceiling { r = l.ceiling / pk }
+
+	l.req[l.pos] = r
+	h := 1.0
+	for _, v := range l.req { h = math.Min(h, v) } // окно короткое — линейный поиск дешевле дека
+	l.boxSum += h - l.hold[l.pos]
+	l.hold[l.pos] = h
+	b := l.boxSum / float64(l.look)
+
+	if b < l.env {
+		l.env = b
+	} else {
+		l.env += (b - l.env) * l.relCoef
+	}
+	if l.env < l.minGain { l.minGain = l.env }
+
+	// кадр, вышедший из задержки (look-1 кадров назад), заменяется новым
+	old := (l.pos + 1) % l.look
+	d := l.delay[old*l.ch : old*l.ch+l.ch]
+	if l.fed >= int64(l.look-1) {
+		for _, v := range d {
+			x := float64(v) * l.env
+			if x > l.ceiling { x = l.ceiling } // страховка от ошибок округления
+			if x < -l.ceiling { x = -l.ceiling }
+			l.out = append(l.out, float32(x))
+		}
+	}
+	copy(l.delay[l.pos*l.ch:], in)
+	l.pos = (l.pos + 1) % l.look
+	l.fed++
+}
@@ -0,0 +1,51 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\limiter_test.go
+// Package: app
+// Назначение: Тесты лимитера: потолок не превышается, длина потока сохраняется, тихий сигнал не меняется.
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// limitAll прогоняет in через лимитер неровными порциями и дописывает Flush.
+func limitAll(l *limiter, in []float32, ch int) []float32 {
+	var out []float32
+	rnd := rand.New(rand.NewSource(1))
+	for len(in) > 0 {
+		k := min(len(in), (1+rnd.Intn(3000))*ch)
+		out = append(out, l.Process(in[:k])...)
+		in = in[k:]
+	}
+	return append(out, l.Flush()...)
+}
+
+func TestLimiterCeiling(t *testing.T) {
+	const rate, ch = 48000, 2
+	ceiling := math.Pow(10, -0.3/20)
+	// шум с пиками до ×4 (+12 дБ) и отдельные импульсы
+	rnd := rand.New(rand.NewSource(7))
+	in := make([]float32, rate*ch)
+	for i := range in { in[i] = float32((rnd.Float64()*2 - 1) * 4) }
+	for i := 1000; i < len(in); i += 7919 { in[i] = 8 }
+
+	out := limitAll(newLimiter(ch, rate, -0.3, 5, 50), in, ch)
+	if len(out) != len(in) { t.Fatalf("длина %d, ожидалось %d", len(out), len(in)) }
+	for i, v := range out {
+		if math.Abs(float64(v)) > ceiling+1e-6 { t.Fatalf("сэмпл %d: %g выше потолка %g", i, v, ceiling) }
+	}
+}
+
+func TestLimiterTransparent(t *testing.T) {
+	// сигнал ниже потолка проходит без изменений и без сдвига во времени
+	const rate, ch = 44100, 1
+	in := make([]float32, rate/2)
+	for i := range in { in[i] = float32(0.5 * math.Sin(float64(i)*0.01)) }
+	out := limitAll(newLimiter(ch, rate, -1, 5, 50), in, ch)
+	if len(out) != len(in) { t.Fatalf("длина %d, ожидалось %d", len(out), len(in)) }
+	for i := range in {
+		if out[i] != in[i] { t.Fatalf("сэмпл %d: %g, ожидалось %g", i, out[i], in[i]) }
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\list.go
+// Package: app
+// Назначение: Явный список файлов (--list): M3U, простой текст или CSV с gain/trim/gap по каждому файлу.
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listTiming — времена из CSV; в кадры переводятся, когда известна выходная частота.
+type listTiming struct {
+	TrimIn  time.Duration
+	TrimOut time.Duration
+	Gap     time.Duration
+}
+
+// readFileList читает список в заданном порядке. Относительные пути — от папки списка.
+// M3U (.m3u/.m3u8) и текст: путь на строку, # — комментарий.
+// CSV (.csv): path[,gain_db[,trim_in[,trim_out[,gap]]]]; строка-заголовок с "path" пропускается.
+// Обрезка сверяется с длиной файла по заголовку сразу, с номером строки; нечитаемый заголовок
+// здесь не ошибка — такой файл отдаётся --on-error при проверке.
+func readFileList(listPath string, repair bool) ([]fileInfo, []listTiming, error) {
+	f, err := os.Open(listPath)
+	if err != nil { return nil, nil, err }
+	defer f.Close()
+
+	type row struct {
+		line   int
+		fields []string
+	}
+	var rows []row
+	switch strings.ToLower(filepath.Ext(listPath)) {
+	case ".csv":
+		cr := csv.NewReader(f)
+		cr.FieldsPerRecord = -1
+		cr.Comment = '#'
+		cr.TrimLeadingSpace = true
+		for {
+			rec, err := cr.Read()
+			if errors.Is(err, io.EOF) { break }
+			if err != nil { return nil, nil, fmt.Errorf("%s: %w", listPath, err) }
+			line, _ := cr.FieldPos(0)
+			if len(rows) == 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "path") { continue }
+			if strings.TrimSpace(rec[0]) == "" { continue }
+			rows = append(rows, row{line, rec})
+		}
+	default:
+		sc := bufio.NewScanner(f)
+		for n := 1; sc.Scan(); n++ {
+			s := strings.TrimSpace(strings.TrimPrefix(sc.Text(), "\uFEFF"))
+			if s == "" || strings.HasPrefix(s, "#") { continue }
+			rows = append(rows, row{n, []string{s}})
+		}
+		if err := sc.Err(); err != nil { return nil, nil, fmt.Errorf("%s: %w", listPath, err) }
+	}
+
+	base := filepath.Dir(listPath)
+	files := make([]fileInfo, 0, len(rows))
+	timings := make([]listTiming, 0, len(rows))
+	for _, r := range rows {
+		where := fmt.Sprintf("%s:%d", listPath, r.line)
+		p := strings.TrimSpace(strings.TrimPrefix(r.fields[0], "\uFEFF"))
+		p = strings.TrimPrefix(p, "file://")
+		if !filepath.IsAbs(p) { p = filepath.Join(base, p) }
+		st, err := os.Stat(p)
+		if err != nil { return nil, nil, fmt.Errorf("%s: %w", where, err) }
+		if st.IsDir() { return nil, nil, fmt.Errorf("%s: %s — папка, а не файл", where, p) }
+
+		fi := fileInfo{Name: filepath.Base(p), Path: p, ModTime: st.ModTime()}
+		var tm listTiming
+		get := func(i int) string {
+			if i < len(r.fields) { return strings.TrimSpace(r.fields[i]) }
+			return ""
+		}
+		if v := get(1); v != "" {
+			if fi.GainDB, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, nil, fmt.Errorf("%s: gain_db %q: %w", where, v, err)
+			}
+		}
+		for i, dst := range []*time.Duration{&tm.TrimIn, &tm.TrimOut, &tm.Gap} {
+			v := get(2 + i)
+			if v == "" { continue }
+			if *dst, err = parseClock(v); err != nil { return nil, nil, fmt.Errorf("%s: %w", where, err) }
+		}
+		if trim := tm.TrimIn + tm.TrimOut; trim > 0 {
+			if h, err := probeWavHeader(p, repair); err == nil {
+				dur := time.Duration(float64(h.Frames) / float64(h.PCM.SampleRate) * float64(time.Second))
+				if trim >= dur { return nil, nil, fmt.Errorf("%s: обрезка %v+%v не короче файла (%v)", where, tm.TrimIn, tm.TrimOut, dur) }
+			}
+		}
+		files = append(files, fi)
+		timings = append(timings, tm)
+	}
+	return files, timings, nil
+}
+
+// parseClock — секунды ("12.5") или часы:минуты:секунды ("1:02:03.250", "02:03").
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 { return 0, fmt.Errorf("время %q: ожидается сек или [чч:]мм:сс", s) }
+	var sec float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v < 0 { return 0, fmt.Errorf("время %q: ожидается сек или [чч:]мм:сс", s) }
+		sec = sec*60 + v
+	}
+	return time.Duration(sec * float64(time.Second)), nil
+}
+
+// applyListTiming переводит trim/gap из списка в кадры выходной частоты.
+func applyListTiming(files []fileInfo, timings []listTiming, rate int) {
+	frames := func(d time.Duration) int64 { return int64(math.Round(d.Seconds() * float64(rate))) }
+	for i := range timings {
+		files[i].TrimIn += frames(timings[i].TrimIn)
+		files[i].TrimOut += frames(timings[i].TrimOut)
+		files[i].Gap += frames(timings[i].Gap)
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\list_test.go
+// Package: app
+// Назначение: Тесты --list: разбор времени, форматы списка (M3U, текст, CSV), проверка обрезки по длине файла.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClock(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"0":           0,
+		"12.5":        12500 * time.Millisecond,
+		"02:03":       2*time.Minute + 3*time.Second,
+		"1:02:03.250": time.Hour + 2*time.Minute + 3250*time.Millisecond,
+	} {
+		got, err := parseClock(s)
+		if err != nil || got != want { t.Fatalf("%q: %v, %v; ожидалось %v", s, got, err, want) }
+	}
+	for _, s := range []string{"", "abc", "-1", "1:2:3:4", "1:-2"} {
+		if _, err := parseClock(s); err == nil { t.Fatalf("%q: ожидалась ошибка", s) }
+	}
+}
+
+func TestReadFileList(t *testing.T) {
+	dir := t.TempDir()
+	// по 1 с: 16 кГц, моно
+	for _, n := range []string{"a.wav", "b.wav", "c.wav"} {
+		writeTestWav(t, filepath.Join(dir, n), pcmFormat(wavFormatPCM, 16000, 1, 16), make([]float32, 16000), nil)
+	}
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0644); err != nil { t.Fatal(err) }
+		return p
+	}
+	names := func(files []fileInfo) string {
+		var s []string
+		for _, f := range files { s = append(s, f.Name) }
+		return strings.Join(s, ",")
+	}
+
+	files, timings, err := readFileList(write("list.m3u", "\uFEFF#EXTM3U\n#EXTINF:1,b\nb.wav\n\n"+filepath.Join(dir, "a.wav")+"\n"), false)
+	if err != nil { t.Fatal(err) }
+	if names(files) != "b.wav,a.wav" || len(timings) != 2 { t.Fatalf("m3u: %s", names(files)) }
+
+	files, timings, err = readFileList(write("list.csv", "path,gain_db,trim_in,trim_out,gap\n# комментарий\nc.wav,-3,0.25,0:00.5,2\na.wav\n"), false)
+	if err != nil { t.Fatal(err) }
+	if names(files) != "c.wav,a.wav" { t.Fatalf("csv: %s", names(files)) }
+	if files[0].GainDB != -3 || timings[0] != (listTiming{TrimIn: 250 * time.Millisecond, TrimOut: 500 * time.Millisecond, Gap: 2 * time.Second}) {
+		t.Fatalf("csv: gain %g, timing %+v", files[0].GainDB, timings[0])
+	}
+	applyListTiming(files, timings, 48000)
+	if files[0].TrimIn != 12000 || files[0].TrimOut != 24000 || files[0].Gap != 96000 { t.Fatalf("кадры: %+v", files[0]) }
+
+	for body, want := range map[string]string{
+		"a.wav\nmissing.wav\n":   "list.txt:2",
+		"b.wav,x\n":              "gain_db",
+		"b.wav,0,0.6,0.4\n":      "list.csv:1: обрезка", // 0.6+0.4 с — весь файл
+		"a.wav\nb.wav,0,1.5\n":   "list.csv:2: обрезка",
+	} {
+		name := "list.txt"
+		if strings.Contains(body, ",") { name = "list.csv" }
+		_, _, err := readFileList(write(name, body), false)
+		if err == nil || !strings.Contains(err.Error(), want) { t.Fatalf("%q: ошибка %v, ожидалось %q", body, err, want) }
+	}
+}
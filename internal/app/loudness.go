@@ -0,0 +1,262 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\loudness.go
+// Package: app
+// Назначение: Громкость по ITU-R BS.1770 / EBU R128: K-фильтр, гейтинг, LRA (EBU Tech 3342), true-peak.
+
+import "math"
+
+const (
+	lufsAbsGate   = -70.0 // абсолютный гейт, LUFS
+	lufsRelGateI  = -10.0 // относительный гейт для integrated, LU
+	lufsRelGateLR = -20.0 // относительный гейт для LRA, LU
+	lufsHistMin   = -70.0 // гистограммы блоков: [-70; +10) LUFS с шагом 0.01 LU
+	lufsHistMax   = 10.0
+	lufsHistStep  = 0.01
+)
+
+// biquad — фильтр второго порядка (Direct Form II transposed).
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// kWeighting — два каскада K-фильтра (high-shelf + high-pass) для произвольной частоты
+// дискретизации; коэффициенты пересчитываются из аналоговых прототипов BS.1770.
+func kWeighting(rate int) (shelf, hp biquad) {
+	fs := float64(rate)
+
+	f0, g, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf = biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	hp = biquad{b0: 1, b1: -2, b2: 1, a1: 2 * (k*k - 1) / a0, a2: (1 - k/q + k*k) / a0}
+	return
+}
+
+// loudnessHist — гистограмма громкости блоков: число блоков и сумма их энергий в каждом бине.
+type loudnessHist struct {
+	count  []int64
+	energy []float64
+}
+
+func newLoudnessHist() *loudnessHist {
+	n := int((lufsHistMax - lufsHistMin) / lufsHistStep)
+	return &loudnessHist{count: make([]int64, n), energy: make([]float64, n)}
+}
+
+func (h *loudnessHist) add(e float64) {
+	l := energyToLUFS(e)
+	if l < lufsAbsGate { return }
+	i := int((l - lufsHistMin) / lufsHistStep)
+	if i >= len(h.count) { i = len(h.count) - 1 }
+	h.count[i]++
+	h.energy[i] += e
+}
+
+func (h *loudnessHist) binLUFS(i int) float64 { return lufsHistMin + (float64(i)+0.5)*lufsHistStep }
+
+// meanAbove — средняя энергия блоков громче порога (LUFS).
+func (h *loudnessHist) meanAbove(gate float64) (float64, int64) {
+	var sum float64
+	var n int64
+	for i := range h.count {
+		if h.count[i] == 0 || h.binLUFS(i) < gate { continue }
+		sum += h.energy[i]
+		n += h.count[i]
+	}
+	if n == 0 { return 0, 0 }
+	return sum / float64(n), n
+}
+
+// percentile — громкость p-го процентиля среди блоков громче порога.
+func (h *loudnessHist) percentile(gate, p float64) float64 {
+	var total int64
+	for i := range h.count {
+		if h.binLUFS(i) >= gate { total += h.count[i] }
+	}
+	want := int64(math.Ceil(p * float64(total)))
+	if want < 1 { want = 1 }
+	var acc int64
+	for i := range h.count {
+		if h.binLUFS(i) < gate { continue }
+		acc += h.count[i]
+		if acc >= want { return h.binLUFS(i) }
+	}
+	return math.Inf(-1)
+}
+
+func energyToLUFS(e float64) float64 {
+	if e <= 0 { return math.Inf(-1) }
+	return -0.691 + 10*math.Log10(e)
+}
+
+// loudnessMeter — потоковый измеритель: блоки 400 мс (integrated) и 3 с (LRA) с шагом 100 мс.
+// Память ограничена гистограммами, поэтому длина записи не важна.
+type loudnessMeter struct {
+	ch      int
+	weights []float64
+	shelf   []biquad
+	hp      []biquad
+	hop     int       // кадров в 100 мс
+	frames  int       // кадров в текущем 100-мс подблоке
+	acc     float64   // взвешенная сумма квадратов текущего подблока
+	ring    []float64 // энергии последних 30 подблоков (3 с)
+	nSub    int64     // всего подблоков
+	blocks  *loudnessHist
+	short   *loudnessHist
+	tp      *truePeakMeter
+}
+
+func newLoudnessMeter(channels, rate int) *loudnessMeter {
+	m := &loudnessMeter{
+		ch:      channels,
+		weights: make([]float64, channels),
+		shelf:   make([]biquad, channels),
+		hp:      make([]biquad, channels),
+		hop:     int(math.Round(float64(rate) * 0.1)),
+		ring:    make([]float64, 30),
+		blocks:  newLoudnessHist(),
+		short:   newLoudnessHist(),
+		tp:      newTruePeakMeter(channels),
+	}
+	for c := 0; c < channels; c++ {
+		m.weights[c] = 1.0
+		m.shelf[c], m.hp[c] = kWeighting(rate)
+	}
+	if channels == 6 { // 5.1: L R C LFE Ls Rs — LFE не учитывается, surround ×1.41
+		m.weights[3], m.weights[4], m.weights[5] = 0, 1.41, 1.41
+	}
+	return m
+}
+
+// Add принимает интерлив-сэмплы, умноженные на gain.
+func (m *loudnessMeter) Add(p []float32, gain float32) {
+	m.tp.Add(p, gain)
+	for i := 0; i+m.ch <= len(p); i += m.ch {
+		for c := 0; c < m.ch; c++ {
+			x := float64(p[i+c] * gain)
+			y := m.hp[c].process(m.shelf[c].process(x))
+			m.acc += m.weights[c] * y * y
+		}
+		m.frames++
+		if m.frames == m.hop { m.closeSub() }
+	}
+}
+
+func (m *loudnessMeter) closeSub() {
+	m.ring[m.nSub%30] = m.acc
+	m.nSub++
+	m.acc, m.frames = 0, 0
+	sum := func(n int64) float64 {
+		s := 0.0
+		for k := int64(0); k < n; k++ { s += m.ring[(m.nSub-1-k)%30] }
+		return s / float64(n*int64(m.hop))
+	}
+	if m.nSub >= 4 { m.blocks.add(sum(4)) }
+	if m.nSub >= 30 { m.short.add(sum(30)) }
+}
+
+// Integrated — интегральная громкость (LUFS); -Inf, если нет блоков выше гейта.
+func (m *loudnessMeter) Integrated() float64 {
+	mean, n := m.blocks.meanAbove(lufsAbsGate)
+	if n == 0 { return math.Inf(-1) }
+	mean, n = m.blocks.meanAbove(energyToLUFS(mean) + lufsRelGateI)
+	if n == 0 { return math.Inf(-1) }
+	return energyToLUFS(mean)
+}
+
+// Range — LRA (LU): разница 95-го и 10-го процентилей краткосрочной громкости после гейтинга.
+func (m *loudnessMeter) Range() float64 {
+	mean, n := m.short.meanAbove(lufsAbsGate)
+	if n == 0 { return 0 }
+	gate := energyToLUFS(mean) + lufsRelGateLR
+	return m.short.percentile(gate, 0.95) - m.short.percentile(gate, 0.10)
+}
+
+// TruePeak — максимальный межсэмпловый пик (линейный).
+func (m *loudnessMeter) TruePeak() float64 { return m.tp.Peak() }
+
+// ---------- true-peak ----------
+
+const (
+	tpOversample = 4
+	tpTaps       = 12 // отводов на фазу (48 всего, как в BS.1770-4 Annex 2)
+)
+
+// tpCoeffs — полифазный интерполятор ×4: windowed-sinc (Кайзер), по фазам.
+var tpCoeffs = buildTruePeakCoeffs()
+
+func buildTruePeakCoeffs() [tpOversample][tpTaps]float64 {
+	var c [tpOversample][tpTaps]float64
+	const beta = 6.0
+	half := float64(tpTaps) / 2
+	i0 := besselI0(beta)
+	for p := 0; p < tpOversample; p++ {
+		sum := 0.0
+		for j := 0; j < tpTaps; j++ {
+			// отвод j соответствует входному сэмплу x[n-j]; точка интерполяции n-half+1+p/4
+			x := float64(j) - (half - 1) + float64(p)/tpOversample
+			r := x / (half + 1)
+			w := 0.0
+			if r*r < 1 { w = besselI0(beta*math.Sqrt(1-r*r)) / i0 }
+			s := 1.0
+			if x != 0 { s = math.Sin(math.Pi*x) / (math.Pi * x) }
+			c[p][j] = s * w
+			sum += c[p][j]
+		}
+		for j := range c[p] { c[p][j] /= sum } // единичное усиление на DC в каждой фазе
+	}
+	return c
+}
+
+type truePeakMeter struct {
+	ch   int
+	hist [][tpTaps]float64 // последние tpTaps сэмплов по каналам (кольцо)
+	pos  int
+	peak float64
+}
+
+func newTruePeakMeter(channels int) *truePeakMeter {
+	return &truePeakMeter{ch: channels, hist: make([][tpTaps]float64, channels)}
+}
+
+func (t *truePeakMeter) Add(p []float32, gain float32) {
+	for i := 0; i+t.ch <= len(p); i += t.ch {
+		for c := 0; c < t.ch; c++ {
+			x := float64(p[i+c] * gain)
+			if a := math.Abs(x); a > t.peak { t.peak = a }
+			h := &t.hist[c]
+			h[t.pos] = x
+			for ph := 1; ph < tpOversample; ph++ {
+				acc := 0.0
+				for j := 0; j < tpTaps; j++ {
+					acc += tpCoeffs[ph][j] * h[(t.pos-j+tpTaps)%tpTaps]
+				}
+				if a := math.Abs(acc); a > t.peak { t.peak = a }
+			}
+		}
+		t.pos = (t.pos + 1) % tpTaps
+	}
+}
+
+func (t *truePeakMeter) Peak() float64 { return t.peak }
@@ -0,0 +1,50 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\loudness_test.go
+// Package: app
+// Назначение: Тесты измерителя громкости по опорным сигналам EBU Tech 3341/3342.
+
+import (
+	"math"
+	"testing"
+)
+
+// sineDBFS — стерео-синус freq Гц с одинаковым уровнем level дБFS (пик) в обоих каналах.
+func sineDBFS(m *loudnessMeter, rate int, freq, level, sec float64) {
+	a := math.Pow(10, level/20)
+	n := int(sec * float64(rate))
+	buf := make([]float32, 0, 2*4800)
+	for i := 0; i < n; i++ {
+		v := float32(a * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+		buf = append(buf, v, v)
+		if len(buf) == cap(buf) { m.Add(buf, 1); buf = buf[:0] }
+	}
+	m.Add(buf, 1)
+}
+
+func TestLoudnessReference(t *testing.T) {
+	for _, rate := range []int{44100, 48000} {
+		// Tech 3341, случай 1: 1 кГц, −23 дБFS на канал → −23.0 ±0.1 LUFS
+		m := newLoudnessMeter(2, rate)
+		sineDBFS(m, rate, 1000, -23, 20)
+		if got := m.Integrated(); math.Abs(got+23) > 0.1 { t.Fatalf("%d Гц: integrated %.2f LUFS, ожидалось −23.0", rate, got) }
+
+		// случай 2: −33 дБFS → −33.0 ±0.1 LUFS
+		m = newLoudnessMeter(2, rate)
+		sineDBFS(m, rate, 1000, -33, 20)
+		if got := m.Integrated(); math.Abs(got+33) > 0.1 { t.Fatalf("%d Гц: integrated %.2f LUFS, ожидалось −33.0", rate, got) }
+
+		// Tech 3342, случай 1: по 20 с на −20 и −30 дБFS → LRA 10 ±1 LU
+		m = newLoudnessMeter(2, rate)
+		sineDBFS(m, rate, 1000, -20, 20)
+		sineDBFS(m, rate, 1000, -30, 20)
+		if got := m.Range(); math.Abs(got-10) > 1 { t.Fatalf("%d Гц: LRA %.2f LU, ожидалось 10", rate, got) }
+	}
+}
+
+func TestLoudnessSilence(t *testing.T) {
+	// тишина ниже абсолютного гейта: громкость не определена
+	m := newLoudnessMeter(2, 48000)
+	m.Add(make([]float32, 2*48000*2), 1)
+	if got := m.Integrated(); !math.IsInf(got, -1) { t.Fatalf("тишина: %.2f LUFS, ожидалось −Inf", got) }
+}
@@ -0,0 +1,324 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\merge.go
+// Package: app
+// Назначение: Потоковая сшивка сегментов: чтение порциями, ресемплинг на лету, кроссфейд на стыках.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+const streamFrames = 16384 // кадров в одной порции чтения/записи
+
+// segment — источник сэмплов одного файла: WAV-декодер + (опционально) ресемплер.
+// Длина известна заранее из заголовка, поэтому проходы PASS1/PASS2 считают одинаково.
+type segment struct {
+	r    *wavReader
+	rs   *resampler
+	ch   int
+	n    int64     // сэмплов на выходе (интерлив)
+	in   []float32 // буфер чтения для ресемплера
+	out  []float32 // выход ресемплера
+	off  int       // сколько из out уже отдано
+	eof  bool
+	peak float64   // пик прочитанных сэмплов
+	gain float32   // усиление файла (после замера пика); 0 — без изменений
+}
+
+func openSegment(path string, rate int, repair bool) (*segment, error) {
+	r, err := openWav(path, repair)
+	if err != nil { return nil, err }
+	s := &segment{r: r, ch: int(r.PCM.NumChannels), n: r.Samples()}
+	if rate > 0 && int(r.PCM.SampleRate) != rate {
+		s.rs = newResampler(s.ch, int(r.PCM.SampleRate), rate)
+		s.n = s.rs.resampledLen(r.Samples()/int64(s.ch)) * int64(s.ch)
+		s.in = make([]float32, streamFrames*s.ch)
+	}
+	return s, nil
+}
+
+func (s *segment) Len() int64   { return s.n }
+func (s *segment) Close() error { return s.r.Close() }
+
+// Trim отбрасывает in кадров в начале и out в конце (кадры на выходной частоте).
+func (s *segment) Trim(in, out int64) error {
+	ch := int64(s.ch)
+	if (in+out)*ch > s.n { return fmt.Errorf("обрезка %d+%d кадров длиннее сегмента", in, out) }
+	s.n -= (in + out) * ch
+	if in == 0 { return nil }
+	if s.rs == nil { return s.r.Skip(in * ch) }
+	buf := make([]float32, streamFrames*s.ch)
+	for left := in * ch; left > 0; {
+		k := int64(len(buf))
+		if left < k { k = left }
+		n, err := s.read(buf[:k])
+		if errors.Is(err, io.EOF) { return io.ErrUnexpectedEOF }
+		if err != nil { return err }
+		left -= int64(n)
+	}
+	return nil
+}
+
+// Read отдаёт до len(dst) сэмплов; io.EOF — данные кончились.
+func (s *segment) Read(dst []float32) (int, error) {
+	n, err := s.read(dst)
+	updatePeak(&s.peak, dst[:n], 1)
+	if s.gain != 0 {
+		for i := range dst[:n] { dst[i] *= s.gain }
+	}
+	return n, err
+}
+
+func (s *segment) read(dst []float32) (int, error) {
+	if s.rs == nil { return s.r.Read(dst) }
+	for s.off == len(s.out) {
+		if s.eof { return 0, io.EOF }
+		n, err := s.r.Read(s.in)
+		switch {
+		case errors.Is(err, io.EOF):
+			s.out = s.rs.Flush(s.out[:0])
+			s.eof = true
+		case err != nil:
+			return 0, err
+		default:
+			s.out = s.rs.Process(s.in[:n], s.out[:0])
+		}
+		s.off = 0
+	}
+	n := copy(dst, s.out[s.off:])
+	s.off += n
+	return n, nil
+}
+
+func readFullSamples(s *segment, dst []float32) error {
+	for got := 0; got < len(dst); {
+		n, err := s.Read(dst[got:])
+		got += n
+		if errors.Is(err, io.EOF) && got < len(dst) { return io.ErrUnexpectedEOF }
+		if err != nil && !errors.Is(err, io.EOF) { return err }
+	}
+	return nil
+}
+
+// segSpan — где сегмент оказался в итоговом потоке: [Start; End) в сэмплах (интерлив).
+// При кроссфейде соседние интервалы перекрываются на длину фейда.
+type segSpan struct {
+	Start int64
+	End   int64
+	Peak  float64 // пик исходного сегмента (до gain)
+	Gap   int64   // тишины вставлено перед сегментом (gap из --list, заполнение паузы)
+}
+
+// timeline — заполнение пауз: сегмент ставится на своё место по времени начала (fileInfo.Start),
+// если встык он оказался бы раньше более чем на tol кадров. Перекрытия не исправляются.
+type timeline struct {
+	t0   time.Time // время кадра 0 итогового файла
+	rate int
+	tol  int64     // допуск, кадров
+}
+
+// target — кадр итогового файла, с которого должен начаться сегмент; -1 — неизвестно.
+func (tl *timeline) target(fi fileInfo) int64 {
+	if tl == nil || fi.Start.IsZero() { return -1 }
+	f := int64(math.Round(fi.Start.Sub(tl.t0).Seconds()*float64(tl.rate))) + fi.TrimIn
+	if f < 0 { return -1 }
+	return f
+}
+
+// fadeCurve — форма кроссфейда: (gOut, gIn) для доли a ∈ [0; 1).
+type fadeCurve func(a float64) (gOut, gIn float64)
+
+func parseFadeCurve(name string) (fadeCurve, error) {
+	switch strings.ToLower(name) {
+	case "linear", "":
+		return func(a float64) (float64, float64) { return 1 - a, a }, nil
+	case "equal-power":
+		// постоянная мощность: без провала уровня на некоррелированном материале
+		return func(a float64) (float64, float64) {
+			return math.Cos(a * math.Pi / 2), math.Sin(a * math.Pi / 2)
+		}, nil
+	case "s-curve":
+		// приподнятый косинус: плавный вход/выход, сумма = 1
+		return func(a float64) (float64, float64) {
+			in := 0.5 - 0.5*math.Cos(math.Pi*a)
+			return 1 - in, in
+		}, nil
+	case "log":
+		// логарифмические ветви (быстрый вход, поздний спад), нормированные на сумму 1, как s-curve:
+		// без нормировки в середине gOut+gIn ≈ 1.48 — +3.4 дБ на стыке одной и той же записи
+		return func(a float64) (float64, float64) {
+			out, in := math.Log10(1+9*(1-a)), math.Log10(1+9*a)
+			return out / (out + in), in / (out + in)
+		}, nil
+	}
+	return nil, fmt.Errorf("неизвестный --crossfade-curve: %s (linear|equal-power|s-curve|log)", name)
+}
+
+// merger — сшивает сегменты в один поток. Хвост каждого сегмента (fade сэмплов) удерживается
+// до прихода следующего и смешивается с его началом; память ограничена буфером порции и хвостом.
+type merger struct {
+	fade     int
+	ch       int
+	gOut     []float64 // коэффициенты фейда по кадрам
+	gIn      []float64
+	emit     func([]float32) error
+	buf      []float32
+	head     []float32
+	tail     []float32
+	haveTail bool
+	out      int64     // сэмплов выдано
+	spans    []segSpan // по одному на каждый add
+	tol      int64     // допуск паузы, сэмплов (интерлив)
+}
+
+func newMerger(channels, fade int, curve fadeCurve, emit func([]float32) error) *merger {
+	m := &merger{
+		fade: fade,
+		ch:   channels,
+		emit: emit,
+		buf:  make([]float32, streamFrames*channels),
+		head: make([]float32, fade),
+		tail: make([]float32, fade),
+	}
+	frames := fade / channels
+	m.gOut, m.gIn = make([]float64, frames), make([]float64, frames)
+	for k := 0; k < frames; k++ {
+		m.gOut[k], m.gIn[k] = curve(float64(k) / float64(frames))
+	}
+	return m
+}
+
+func (m *merger) put(p []float32) error {
+	if err := m.emit(p); err != nil { return err }
+	m.out += int64(len(p))
+	return nil
+}
+
+// silence выдаёт удержанный хвост без фейда и затем n сэмплов тишины.
+func (m *merger) silence(n int64) error {
+	if m.haveTail {
+		m.haveTail = false
+		if err := m.put(m.tail); err != nil { return err }
+		m.spans[len(m.spans)-1].End = m.out
+	}
+	clear(m.buf)
+	for n > 0 {
+		k := int64(len(m.buf))
+		if n < k { k = n }
+		if err := m.put(m.buf[:k]); err != nil { return err }
+		n -= k
+	}
+	return nil
+}
+
+// add дописывает сегмент. gap — тишина перед ним (сэмплов); at — сэмпл итогового потока,
+// где он должен начаться (-1 — встык).
+func (m *merger) add(s *segment, gap, at int64) error {
+	n := s.Len()
+	var pos int64
+	prev := len(m.spans) - 1
+
+	if gap > 0 {
+		if err := m.silence(gap); err != nil { return err }
+	}
+	if at >= 0 {
+		// пауза меряется от конца предыдущего сегмента без фейда; если она длиннее допуска —
+		// хвост выдаётся без фейда (поток доходит до end), затем тишина до at. Иначе — встык с фейдом
+		end := m.out
+		if m.haveTail { end += int64(m.fade) }
+		if fill := at - end; fill > m.tol {
+			if err := m.silence(fill); err != nil { return err }
+			gap += fill
+		}
+	}
+
+	if m.haveTail {
+		if int64(m.fade) <= n {
+			// смешанный фейд: здесь начинается текущий и заканчивается предыдущий сегмент
+			m.spans = append(m.spans, segSpan{Start: m.out})
+			if err := readFullSamples(s, m.head); err != nil { return err }
+			for k := 0; k < m.fade; k++ {
+				fr := k / m.ch
+				m.head[k] = float32(m.gOut[fr]*float64(m.tail[k]) + m.gIn[fr]*float64(m.head[k]))
+			}
+			if err := m.put(m.head); err != nil { return err }
+			pos = int64(m.fade)
+		} else if err := m.put(m.tail); err != nil {
+			return err
+		}
+		m.haveTail = false
+		m.spans[prev].End = m.out
+	}
+	if len(m.spans) == prev+1 { m.spans = append(m.spans, segSpan{Start: m.out}) }
+	cur := &m.spans[len(m.spans)-1]
+	cur.Gap = gap
+
+	// середина; хвост удерживается, если его хватает
+	hold := int64(0)
+	if m.fade > 0 && n-pos >= int64(m.fade) { hold = int64(m.fade) }
+	for end := n - hold; pos < end; {
+		k := int64(len(m.buf))
+		if end-pos < k { k = end - pos }
+		if err := readFullSamples(s, m.buf[:k]); err != nil { return err }
+		if err := m.put(m.buf[:k]); err != nil { return err }
+		pos += k
+	}
+	cur.End = m.out
+	if hold > 0 {
+		if err := readFullSamples(s, m.tail); err != nil { return err }
+		m.haveTail = true
+	}
+	cur.Peak = s.peak
+	return nil
+}
+
+// finish выдаёт удержанный хвост последнего сегмента.
+func (m *merger) finish() error {
+	if !m.haveTail { return nil }
+	m.haveTail = false
+	if err := m.put(m.tail); err != nil { return err }
+	m.spans[len(m.spans)-1].End = m.out
+	return nil
+}
+
+// mergeFiles прогоняет все файлы через merger; progress вызывается после каждого файла.
+// tl != nil — паузы между файлами заполняются тишиной по времени начала.
+// Возвращает положение каждого файла в итоговом потоке. Под --on-error (*fileError) идут только
+// ошибки открытия и чтения файла; обрезка длиннее файла, чужое число каналов и ошибка emit
+// (записи итога) — обычные ошибки: пропуск файла их не исправит.
+func mergeFiles(files []fileInfo, rate, channels, fade int, curve fadeCurve, tl *timeline, repair bool, emit func([]float32) error, progress func(done int)) ([]segSpan, error) {
+	var emitErr error
+	m := newMerger(channels, fade, curve, func(p []float32) error {
+		emitErr = emit(p)
+		return emitErr
+	})
+	m.spans = make([]segSpan, 0, len(files))
+	if tl != nil { m.tol = tl.tol * int64(channels) }
+	progress(0)
+	for i, fi := range files {
+		s, err := openSegment(fi.Path, rate, repair)
+		if err != nil { return nil, &fileError{i, fi.Path, err} }
+		switch {
+		case s.ch != channels:
+			err = fmt.Errorf("%s: %d каналов, в итоге %d", fi.Path, s.ch, channels)
+		case (fi.TrimIn+fi.TrimOut)*int64(s.ch) > s.Len():
+			err = fmt.Errorf("%s: обрезка %d+%d кадров длиннее файла (%d кадров)", fi.Path, fi.TrimIn, fi.TrimOut, s.Len()/int64(s.ch))
+		}
+		if err != nil { s.Close(); return nil, err }
+		if fi.GainDB != 0 { s.gain = float32(math.Pow(10, fi.GainDB/20)) }
+		at := tl.target(fi)
+		if at >= 0 { at *= int64(channels) }
+		if err = s.Trim(fi.TrimIn, fi.TrimOut); err == nil { err = m.add(s, fi.Gap*int64(channels), at) }
+		s.Close()
+		if emitErr != nil { return nil, emitErr }
+		if err != nil { return nil, &fileError{i, fi.Path, err} }
+		progress(i + 1)
+	}
+	if err := m.finish(); err != nil { return nil, err }
+	return m.spans, nil
+}
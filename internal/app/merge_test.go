@@ -0,0 +1,153 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\merge_test.go
+// Package: app
+// Назначение: Тесты потоковой сшивки: длина потока и положение сегментов (segSpan) с кроссфейдом и без, формы кроссфейда,
+// какие ошибки идут под --on-error (*fileError), а какие — нет.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeDC пишет float-файл с постоянным уровнем v: при линейном фейде (gOut+gIn = 1)
+// сшитый поток должен оставаться равным v во всех точках, включая стыки.
+func writeDC(t *testing.T, dir string, i, frames, ch int, v float32) fileInfo {
+	t.Helper()
+	name := fmt.Sprintf("f%d.wav", i)
+	path := filepath.Join(dir, name)
+	in := make([]float32, frames*ch)
+	for k := range in { in[k] = v }
+	writeTestWav(t, path, pcmFormat(wavFormatFloat, 16000, uint16(ch), 32), in, nil)
+	return fileInfo{Name: name, Path: path}
+}
+
+func TestMergeFilesSpans(t *testing.T) {
+	const rate, ch = 16000, 2
+	const dc = 0.5
+	linear, err := parseFadeCurve("linear")
+	if err != nil { t.Fatal(err) }
+
+	cases := []struct {
+		name    string
+		frames  []int
+		fade    int  // кадров
+		overlap []bool // перекрывается ли сегмент i с предыдущим на fade
+	}{
+		{"no-fade", []int{4000, 2500, 3000}, 0, []bool{false, false, false}},
+		{"fade", []int{4000, 2500, 3000}, 160, []bool{false, true, true}},
+		// сегмент короче фейда стыкуется встык с обеих сторон: хвост предыдущего уходит без фейда,
+		// а сам он хвост не удерживает
+		{"short-middle", []int{4000, 100, 3000}, 160, []bool{false, false, false}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := make([]fileInfo, len(tc.frames))
+			for i, n := range tc.frames { files[i] = writeDC(t, dir, i, n, ch, dc) }
+
+			run := func() ([]segSpan, []float32) {
+				var out []float32
+				emit := func(p []float32) error { out = append(out, p...); return nil }
+				spans, err := mergeFiles(files, rate, ch, tc.fade*ch, linear, nil, false, emit, func(int) {})
+				if err != nil { t.Fatal(err) }
+				return spans, out
+			}
+			spans, out := run()
+
+			fade := int64(tc.fade * ch)
+			want := int64(0)
+			for i, n := range tc.frames {
+				want += int64(n * ch)
+				if tc.overlap[i] { want -= fade }
+			}
+			if int64(len(out)) != want { t.Fatalf("длина потока %d, ожидалось %d", len(out), want) }
+			if len(spans) != len(files) { t.Fatalf("%d интервалов на %d файлов", len(spans), len(files)) }
+			if spans[0].Start != 0 { t.Fatalf("первый сегмент с %d, ожидалось 0", spans[0].Start) }
+			if last := spans[len(spans)-1]; last.End != want { t.Fatalf("последний сегмент до %d, поток %d", last.End, want) }
+			for i, sp := range spans {
+				if got := sp.End - sp.Start; got != int64(tc.frames[i]*ch) {
+					t.Fatalf("сегмент %d: длина %d, ожидалось %d", i, got, tc.frames[i]*ch)
+				}
+				if sp.Gap != 0 { t.Fatalf("сегмент %d: gap %d без пауз", i, sp.Gap) }
+				if math.Abs(sp.Peak-dc) > 1e-6 { t.Fatalf("сегмент %d: пик %g, ожидалось %g", i, sp.Peak, dc) }
+				if i == 0 { continue }
+				wantStart := spans[i-1].End
+				if tc.overlap[i] { wantStart -= fade }
+				if sp.Start != wantStart { t.Fatalf("сегмент %d: начало %d, ожидалось %d", i, sp.Start, wantStart) }
+			}
+			for k, v := range out {
+				if math.Abs(float64(v)-dc) > 1e-6 { t.Fatalf("сэмпл %d: %g, ожидалось %g (стык с провалом/выбросом)", k, v, dc) }
+			}
+
+			// PASS1 и PASS2 прогоняют один и тот же merger — раскладка должна совпадать точно
+			spans2, out2 := run()
+			if !reflect.DeepEqual(spans, spans2) || len(out2) != len(out) { t.Fatalf("повторный прогон дал другую раскладку") }
+		})
+	}
+}
+
+// TestFadeCurves — края кривых и сохранение уровня: сумма 1 (linear, s-curve, log) или мощность 1 (equal-power).
+func TestFadeCurves(t *testing.T) {
+	for _, name := range []string{"linear", "equal-power", "s-curve", "log"} {
+		curve, err := parseFadeCurve(name)
+		if err != nil { t.Fatal(err) }
+		if o, i := curve(0); o != 1 || i != 0 { t.Fatalf("%s(0) = %g, %g; ожидалось 1, 0", name, o, i) }
+		prevIn := -1.0
+		for k := 0; k < 100; k++ {
+			a := float64(k) / 100
+			o, i := curve(a)
+			sum := o + i
+			if name == "equal-power" { sum = o*o + i*i }
+			if math.Abs(sum-1) > 1e-9 { t.Fatalf("%s(%g) = %g, %g: сумма %g, ожидалось 1", name, a, o, i, sum) }
+			if i <= prevIn { t.Fatalf("%s: gIn не растёт на %g", name, a) }
+			prevIn = i
+		}
+	}
+}
+
+func TestMergeFilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	linear, err := parseFadeCurve("linear")
+	if err != nil { t.Fatal(err) }
+	run := func(files []fileInfo, emit func([]float32) error) error {
+		_, err := mergeFiles(files, 16000, 1, 0, linear, nil, false, emit, func(int) {})
+		return err
+	}
+	discard := func([]float32) error { return nil }
+	a, b := writeDC(t, dir, 0, 100, 1, 0.5), writeDC(t, dir, 1, 100, 1, 0.5)
+
+	// нет файла — ошибка чтения, идёт под --on-error с индексом файла
+	missing := fileInfo{Name: "none.wav", Path: filepath.Join(dir, "none.wav")}
+	var fe *fileError
+	if err := run([]fileInfo{a, missing}, discard); !errors.As(err, &fe) || fe.Index != 1 {
+		t.Fatalf("нет файла: %v, ожидался *fileError с Index 1", err)
+	}
+
+	// обрезка длиннее файла — ошибка настройки, файл исправен
+	long := b
+	long.TrimIn, long.TrimOut = 60, 50
+	if err := run([]fileInfo{a, long}, discard); err == nil || errors.As(err, &fe) {
+		t.Fatalf("обрезка 60+50 из 100: %v, ожидалась ошибка не *fileError", err)
+	}
+	exact := b
+	exact.TrimIn, exact.TrimOut = 60, 40
+	if err := run([]fileInfo{a, exact}, discard); err != nil { t.Fatalf("обрезка 60+40 из 100: %v", err) }
+
+	// чужое число каналов — ошибка настройки
+	stereo := writeDC(t, dir, 2, 100, 2, 0.5)
+	if err := run([]fileInfo{a, stereo}, discard); err == nil || errors.As(err, &fe) {
+		t.Fatalf("стерео в моно-итоге: %v, ожидалась ошибка не *fileError", err)
+	}
+
+	// ошибка записи итога возвращается как есть
+	errDisk := errors.New("диск заполнен")
+	fail := func([]float32) error { return errDisk }
+	if err := run([]fileInfo{a, b}, fail); !errors.Is(err, errDisk) || errors.As(err, &fe) {
+		t.Fatalf("ошибка emit: %v, ожидалась %v без *fileError", err, errDisk)
+	}
+}
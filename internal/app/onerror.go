@@ -0,0 +1,130 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\onerror.go
+// Package: app
+// Назначение: Реакция на повреждённые входные файлы (--on-error skip|quarantine|abort): проверка, пропуск, карантин.
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"acousticmerge/internal/ui"
+)
+
+// fileError — ошибка чтения файла files[Index] (текст — как прежде: "путь: ошибка").
+type fileError struct {
+	Index int
+	Path  string
+	Err   error
+}
+
+func (e *fileError) Error() string { return e.Path + ": " + e.Err.Error() }
+func (e *fileError) Unwrap() error { return e.Err }
+
+// badFile — файл, исключённый из склейки, и причина.
+type badFile struct {
+	fileInfo
+	Err error
+}
+
+// errorPolicy копит пропущенные файлы; в режиме abort ничего не принимает.
+type errorPolicy struct {
+	mode string
+	bad  []badFile
+}
+
+// take — можно ли продолжить без файла (тогда он запоминается для отчёта).
+func (p *errorPolicy) take(fi fileInfo, err error) bool {
+	if p.mode == "abort" { return false }
+	p.bad = append(p.bad, badFile{fi, err})
+	return true
+}
+
+func (p *errorPolicy) files() []fileInfo {
+	out := make([]fileInfo, 0, len(p.bad))
+	for _, b := range p.bad { out = append(out, b.fileInfo) }
+	return out
+}
+
+// verifyFile — заголовок разбирается, и data целиком помещается в файле (обрезанный хвост
+// иначе обнаружился бы только посреди PASS2). repaired — что исправлено в режиме --repair.
+func verifyFile(path string, repair bool) (repaired string, err error) {
+	r, err := openWav(path, repair)
+	if err != nil { return "", err }
+	defer r.Close()
+	st, err := r.f.Stat()
+	if err != nil { return "", err }
+	if end := r.dataOff + r.left; end > st.Size() {
+		return "", fmt.Errorf("файл обрезан: data до байта %d, размер файла %d", end, st.Size())
+	}
+	return r.Repaired, nil
+}
+
+// verifyFiles проверяет все файлы до PASS1; не прошедшие проверку отдаются policy,
+// восстановленные (--repair) — в onRepair. timings (из --list) прореживаются вместе с файлами.
+func verifyFiles(files []fileInfo, timings []listTiming, repair bool, p *errorPolicy, onRepair func(fileInfo, string), progress func(done int)) ([]fileInfo, []listTiming, error) {
+	kept := files[:0:0]
+	var keptT []listTiming
+	progress(0)
+	for i, fi := range files {
+		repaired, err := verifyFile(fi.Path, repair)
+		if repaired != "" { onRepair(fi, repaired) }
+		if err != nil {
+			if !p.take(fi, err) { return nil, nil, fmt.Errorf("%s: %w", fi.Path, err) }
+		} else {
+			kept = append(kept, fi)
+			if timings != nil { keptT = append(keptT, timings[i]) }
+		}
+		progress(i + 1)
+	}
+	return kept, keptT, nil
+}
+
+// quarantine переносит файл в dir с сохранением пути относительно src; занятые имена — как для --out.
+func quarantine(path, src, dir string) (string, error) {
+	rel, err := filepath.Rel(src, path)
+	if err != nil || strings.HasPrefix(rel, "..") { rel = filepath.Base(path) }
+	dst, err := nextAvailablePath(filepath.Join(dir, rel))
+	if err != nil { return "", err }
+	if err := ensureDir(filepath.Dir(dst)); err != nil { return "", err }
+	if err := os.Rename(path, dst); err == nil { return dst, nil }
+	// другой диск: копия, затем удаление исходника
+	if err := copyFile(path, dst); err != nil { os.Remove(dst); return "", err }
+	return dst, os.Remove(path)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil { return err }
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil { return err }
+	if _, err := io.Copy(out, in); err != nil { out.Close(); return err }
+	return out.Close()
+}
+
+// reportBad — итоговый список пропущенных файлов; quarantine переносит их в cfg.QuarantineDir
+// (при --dry-run файлы остаются на месте). Возвращает число пропущенных.
+func reportBad(cfg *Config, U ui.UIAPI, p *errorPolicy) int {
+	if len(p.bad) == 0 { return 0 }
+	U.LogWarn("skipped: %d повреждённых файлов (--on-error %s)", len(p.bad), p.mode)
+	for _, b := range p.bad {
+		switch {
+		case p.mode != "quarantine":
+			U.LogWarn("  %s: %v", b.Path, b.Err)
+		case cfg.DryRun:
+			U.LogWarn("  %s: %v (dry-run: не перенесён)", b.Path, b.Err)
+		default:
+			dst, err := quarantine(b.Path, cfg.Src, cfg.QuarantineDir)
+			if err != nil {
+				U.LogErr("  %s: %v; перенос в карантин не удался: %v", b.Path, b.Err, err)
+				continue
+			}
+			U.LogWarn("  %s: %v → %s", b.Path, b.Err, dst)
+		}
+	}
+	return len(p.bad)
+}
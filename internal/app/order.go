@@ -0,0 +1,62 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\order.go
+// Package: app
+// Назначение: Естественная сортировка путей: seg_2 < seg_10, каталоги сравниваются по уровням.
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// naturalPathLess сравнивает пути по компонентам (каталог за каталогом), каждый — naturalLess.
+// Так файлы одного каталога идут подряд, а day_2/ раньше day_10/.
+func naturalPathLess(a, b string) bool {
+	pa := strings.Split(filepath.ToSlash(a), "/")
+	pb := strings.Split(filepath.ToSlash(b), "/")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] == pb[i] { continue }
+		if c := naturalCompare(pa[i], pb[i]); c != 0 { return c < 0 }
+	}
+	return len(pa) < len(pb)
+}
+
+// naturalCompare: цифровые серии сравниваются как числа (любой длины), остальное — без учёта регистра.
+// При равенстве по значению короче та серия, где меньше ведущих нулей; затем — побайтово.
+func naturalCompare(a, b string) int {
+	la, lb := strings.ToLower(a), strings.ToLower(b)
+	i, j := 0, 0
+	zeros := 0 // разница ведущих нулей первой несовпавшей по записи серии
+	for i < len(la) && j < len(lb) {
+		ca, cb := la[i], lb[j]
+		if isDigit(ca) && isDigit(cb) {
+			si, sj := i, j
+			for i < len(la) && isDigit(la[i]) { i++ }
+			for j < len(lb) && isDigit(lb[j]) { j++ }
+			na := strings.TrimLeft(la[si:i], "0")
+			nb := strings.TrimLeft(lb[sj:j], "0")
+			if len(na) != len(nb) { return cmpInt(len(na), len(nb)) }
+			if na != nb { return strings.Compare(na, nb) }
+			if zeros == 0 { zeros = cmpInt(i-si, j-sj) }
+			continue
+		}
+		if ca != cb { return cmpInt(int(ca), int(cb)) }
+		i++
+		j++
+	}
+	if c := cmpInt(len(la)-i, len(lb)-j); c != 0 { return c }
+	if zeros != 0 { return zeros }
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
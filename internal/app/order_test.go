@@ -0,0 +1,48 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\order_test.go
+// Package: app
+// Назначение: Тесты естественной сортировки имён и путей.
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNaturalCompare(t *testing.T) {
+	want := []string{
+		"seg_1.wav",
+		"seg_01.wav", // то же число, больше ведущих нулей — позже
+		"seg_2.wav",
+		"Seg_3.wav", // регистр не учитывается
+		"seg_10.wav",
+		"seg_10a.wav",
+		"seg_100.wav",
+		"seg_99999999999999999999.wav", // длиннее int64
+		"seg_100000000000000000000.wav",
+		"segment.wav",
+	}
+	got := append([]string(nil), want...)
+	rand.New(rand.NewSource(3)).Shuffle(len(got), func(i, j int) { got[i], got[j] = got[j], got[i] })
+	sort.Slice(got, func(i, j int) bool { return naturalCompare(got[i], got[j]) < 0 })
+	if !reflect.DeepEqual(got, want) { t.Fatalf("порядок:\n%q\nожидалось:\n%q", got, want) }
+
+	// полный порядок: антисимметрия и равенство только с самим собой (sort.Slice не стабилен)
+	all := append(want, "SEG_1.wav", "seg_001.wav")
+	for _, a := range all {
+		for _, b := range all {
+			ab, ba := naturalCompare(a, b), naturalCompare(b, a)
+			if ab != -ba { t.Fatalf("%q/%q: %d и %d", a, b, ab, ba) }
+			if (ab == 0) != (a == b) { t.Fatalf("%q/%q: %d", a, b, ab) }
+		}
+	}
+}
+
+func TestNaturalPathLess(t *testing.T) {
+	want := []string{"day_2/a9.wav", "day_2/a10.wav", "day_2/z.wav", "day_10/a.wav", "day_10/sub/a.wav"}
+	got := []string{"day_10/sub/a.wav", "day_2/z.wav", "day_10/a.wav", "day_2/a10.wav", "day_2/a9.wav"}
+	sort.Slice(got, func(i, j int) bool { return naturalPathLess(got[i], got[j]) })
+	if !reflect.DeepEqual(got, want) { t.Fatalf("порядок:\n%q\nожидалось:\n%q", got, want) }
+}
@@ -0,0 +1,137 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\resample.go
+// Package: app
+// Назначение: Потоковый ресемплер (windowed-sinc с окном Кайзера) для приведения частоты дискретизации.
+
+import "math"
+
+const (
+	rsZeroCross  = 32   // пересечений нуля sinc с каждой стороны ядра
+	rsTableRes   = 512  // точек таблицы ядра на одно пересечение нуля
+	rsKaiserBeta = 8.6  // ~ -90 дБ подавления боковых лепестков
+	rsRolloff    = 0.95 // доля полосы Найквиста, которую пропускает фильтр
+)
+
+// rsKernel — правая половина ядра h(x) = sinc(x)·kaiser(x), x ∈ [0; rsZeroCross], шаг 1/rsTableRes.
+var rsKernel = buildResampleKernel()
+
+func buildResampleKernel() []float64 {
+	n := rsZeroCross*rsTableRes + 2 // +запас под линейную интерполяцию
+	k := make([]float64, n)
+	i0Beta := besselI0(rsKaiserBeta)
+	for i := range k {
+		x := float64(i) / rsTableRes
+		if x >= rsZeroCross { continue }
+		r := x / rsZeroCross
+		win := besselI0(rsKaiserBeta*math.Sqrt(1-r*r)) / i0Beta
+		s := 1.0
+		if x != 0 { s = math.Sin(math.Pi*x) / (math.Pi * x) }
+		k[i] = s * win
+	}
+	return k
+}
+
+// besselI0 — модифицированная функция Бесселя первого рода нулевого порядка (ряд).
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	h := x / 2
+	for k := 1; k < 64; k++ {
+		term *= (h / float64(k)) * (h / float64(k))
+		sum += term
+		if term < sum*1e-12 { break }
+	}
+	return sum
+}
+
+// resampler — ресемплер с произвольным рациональным отношением частот (алгоритм Смита,
+// band-limited interpolation). Принимает интерлив-кадры порциями, края дополняет
+// крайними кадрами, поэтому длина результата детерминирована: resampledLen(N).
+type resampler struct {
+	ch      int
+	inRate  int64
+	outRate int64
+	fc      float64   // частота среза относительно входного Найквиста
+	span    int64     // входных кадров по каждую сторону от точки интерполяции
+	weights []float64 // веса текущего выходного кадра (2*span)
+	first   []float32 // первый входной кадр (дополнение слева)
+	buf     []float32 // окно входных кадров (интерлив)
+	bufPos  int64     // абсолютный индекс кадра buf[0]
+	inN     int64     // принято входных кадров
+	outN    int64     // выдано выходных кадров
+}
+
+func newResampler(channels, inRate, outRate int) *resampler {
+	r := &resampler{ch: channels, inRate: int64(inRate), outRate: int64(outRate), fc: rsRolloff}
+	if outRate < inRate {
+		r.fc = rsRolloff * float64(outRate) / float64(inRate)
+	}
+	r.span = int64(math.Ceil(rsZeroCross/r.fc)) + 1
+	r.weights = make([]float64, 2*r.span)
+	return r
+}
+
+// resampledLen — сколько кадров получится из frames входных кадров.
+func (r *resampler) resampledLen(frames int64) int64 {
+	return (frames*r.outRate + r.inRate - 1) / r.inRate
+}
+
+// Process принимает интерлив-кадры и дописывает в dst все выходные кадры, которые уже можно вычислить.
+func (r *resampler) Process(in []float32, dst []float32) []float32 {
+	if len(in) < r.ch { return dst }
+	if r.first == nil {
+		r.first = append([]float32(nil), in[:r.ch]...)
+	}
+	r.buf = append(r.buf, in...)
+	r.inN += int64(len(in) / r.ch)
+	return r.produce(dst, false)
+}
+
+// Flush выдаёт оставшиеся кадры (правый край дополняется последним кадром).
+func (r *resampler) Flush(dst []float32) []float32 { return r.produce(dst, true) }
+
+func (r *resampler) produce(dst []float32, final bool) []float32 {
+	total := r.resampledLen(r.inN)
+	for r.outN < total {
+		num := r.outN * r.inRate
+		i0 := num / r.outRate
+		if !final && i0+r.span >= r.inN { break }
+		frac := float64(num%r.outRate) / float64(r.outRate)
+		lo := i0 - r.span + 1
+		for j := range r.weights {
+			d := math.Abs((float64(i0-lo-int64(j)) + frac) * r.fc)
+			r.weights[j] = r.fc * kernelAt(d)
+		}
+		for c := 0; c < r.ch; c++ {
+			acc := 0.0
+			for j, w := range r.weights {
+				if w != 0 { acc += w * float64(r.sample(lo+int64(j), c)) }
+			}
+			dst = append(dst, float32(acc))
+		}
+		r.outN++
+	}
+
+	// отбросить кадры, которые больше не понадобятся
+	keep := (r.outN*r.inRate)/r.outRate - r.span + 1
+	if keep > r.inN { keep = r.inN }
+	if drop := keep - r.bufPos; drop > 0 {
+		r.buf = append(r.buf[:0], r.buf[drop*int64(r.ch):]...)
+		r.bufPos = keep
+	}
+	return dst
+}
+
+func (r *resampler) sample(k int64, c int) float32 {
+	if k < 0 { return r.first[c] }
+	if k >= r.inN { k = r.inN - 1 }
+	return r.buf[(k-r.bufPos)*int64(r.ch)+int64(c)]
+}
+
+func kernelAt(x float64) float64 {
+	if x >= rsZeroCross { return 0 }
+	p := x * rsTableRes
+	i := int(p)
+	f := p - float64(i)
+	return rsKernel[i] + (rsKernel[i+1]-rsKernel[i])*f
+}
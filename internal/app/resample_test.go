@@ -0,0 +1,57 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\resample_test.go
+// Package: app
+// Назначение: Тесты ресемплера: длина результата (как обещает resampledLen) и сохранение уровня.
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// resampleAll прогоняет in порциями по chunk кадров и сбрасывает остаток.
+func resampleAll(rs *resampler, in []float32, ch, chunk int) []float32 {
+	var out []float32
+	for len(in) > 0 {
+		k := min(len(in), chunk*ch)
+		out = rs.Process(in[:k], out)
+		in = in[k:]
+	}
+	return rs.Flush(out)
+}
+
+func TestResamplerLength(t *testing.T) {
+	for _, rates := range [][2]int{{44100, 48000}, {48000, 44100}, {16000, 48000}, {48000, 8000}, {22050, 16000}} {
+		for _, frames := range []int{1, 37, 4410, 48001} {
+			t.Run(fmt.Sprintf("%d-%d/%d", rates[0], rates[1], frames), func(t *testing.T) {
+				const ch = 2
+				in := testSignal(frames, ch)
+				want := newResampler(ch, rates[0], rates[1]).resampledLen(int64(frames)) * ch
+				// длина не зависит от размера порций: PASS1 и PASS2 должны совпасть
+				for _, chunk := range []int{1, 1000, streamFrames} {
+					out := resampleAll(newResampler(ch, rates[0], rates[1]), in, ch, chunk)
+					if int64(len(out)) != want { t.Fatalf("порции по %d: %d сэмплов, ожидалось %d", chunk, len(out), want) }
+				}
+			})
+		}
+	}
+}
+
+func TestResamplerLevel(t *testing.T) {
+	const inRate, outRate, frames = 44100, 48000, 44100
+	// постоянный уровень: края дополняются крайними кадрами, поэтому он держится до самого конца
+	dc := make([]float32, frames)
+	for i := range dc { dc[i] = 0.5 }
+	for i, v := range resampleAll(newResampler(1, inRate, outRate), dc, 1, 4096) {
+		if math.Abs(float64(v)-0.5) > 1e-3 { t.Fatalf("DC: кадр %d = %g, ожидалось 0.5", i, v) }
+	}
+
+	// тон 1 кГц в полосе пропускания: амплитуда в середине (вдали от краёв) сохраняется
+	tone := make([]float32, frames)
+	for i := range tone { tone[i] = float32(0.5 * math.Sin(2*math.Pi*1000*float64(i)/inRate)) }
+	out := resampleAll(newResampler(1, inRate, outRate), tone, 1, 4096)
+	var peak float64
+	for _, v := range out[outRate/4 : 3*outRate/4] { peak = math.Max(peak, math.Abs(float64(v))) }
+	if math.Abs(peak-0.5) > 0.005 { t.Fatalf("тон 1 кГц: пик %g, ожидалось 0.5", peak) }
+}
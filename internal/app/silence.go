@@ -0,0 +1,105 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\silence.go
+// Package: app
+// Назначение: Поиск тишины по файлам (RMS/пик, тихие края) — пропуск тихих сегментов и обрезка краёв.
+
+import (
+	"fmt"
+	"math"
+)
+
+// segStats — уровни одного файла на выходной частоте (до gain).
+type segStats struct {
+	Frames int64
+	Peak   float64
+	RMS    float64
+	Lead   int64 // тихих кадров в начале
+	Trail  int64 // тихих кадров в конце
+}
+
+// analyzeSegment читает файл целиком (через тот же segment, что и merger, — длины совпадают;
+// уже заданная обрезка учитывается). Кадр тихий, если модуль всех каналов ниже thr.
+func analyzeSegment(fi fileInfo, rate int, thr float64, repair bool) (segStats, error) {
+	s, err := openSegment(fi.Path, rate, repair)
+	if err != nil { return segStats{}, err }
+	defer s.Close()
+	if err := s.Trim(fi.TrimIn, fi.TrimOut); err != nil { return segStats{}, err }
+
+	ch := int64(s.ch)
+	st := segStats{Frames: s.Len() / ch}
+	buf := make([]float32, streamFrames*s.ch)
+	var sumSq float64
+	var frame int64
+	first, last := int64(-1), int64(-1)
+	for left := s.Len(); left > 0; {
+		k := int64(len(buf))
+		if left < k { k = left }
+		if err := readFullSamples(s, buf[:k]); err != nil { return segStats{}, err }
+		for i := int64(0); i+ch <= k; i += ch {
+			loud := false
+			for _, v := range buf[i : i+ch] {
+				x := float64(v)
+				sumSq += x * x
+				if math.Abs(x) >= thr { loud = true }
+			}
+			if loud {
+				if first < 0 { first = frame }
+				last = frame
+			}
+			frame++
+		}
+		left -= k
+	}
+	st.Peak = s.peak
+	if s.Len() > 0 { st.RMS = math.Sqrt(sumSq / float64(s.Len())) }
+	if first < 0 {
+		st.Lead, st.Trail = st.Frames, 0
+	} else {
+		st.Lead, st.Trail = first, st.Frames-1-last
+	}
+	return st, nil
+}
+
+// silenceResult — что убрано из потока.
+type silenceResult struct {
+	Dropped       []fileInfo
+	DroppedFrames int64
+	TrimmedFrames int64
+}
+
+// applySilence отбрасывает файлы с RMS ниже skipDB и/или срезает тихие края (trimDB).
+// NaN — соответствующий режим выключен. Кадры — на выходной частоте.
+// onErr решает, можно ли продолжить без нечитаемого файла (--on-error).
+func applySilence(files []fileInfo, rate int, skipDB, trimDB float64, repair bool, onErr func(fileInfo, error) bool, progress func(done int)) ([]fileInfo, silenceResult, error) {
+	thr := math.Inf(1) // без обрезки края не ищутся
+	if !math.IsNaN(trimDB) { thr = math.Pow(10, trimDB/20) }
+	var res silenceResult
+	kept := files[:0:0]
+	progress(0)
+	for i, fi := range files {
+		st, err := analyzeSegment(fi, rate, thr, repair)
+		progress(i + 1)
+		if err != nil {
+			if onErr(fi, err) { continue }
+			return nil, res, fmt.Errorf("%s: %w", fi.Path, err)
+		}
+		if !math.IsNaN(skipDB) && toDB(st.RMS) < skipDB {
+			res.Dropped = append(res.Dropped, fi)
+			res.DroppedFrames += st.Frames
+			continue
+		}
+		if !math.IsNaN(trimDB) {
+			if st.Lead == st.Frames { // тишина целиком — после обрезки ничего не остаётся
+				res.Dropped = append(res.Dropped, fi)
+				res.DroppedFrames += st.Frames
+				continue
+			}
+			fi.TrimIn += st.Lead
+			fi.TrimOut += st.Trail
+			res.TrimmedFrames += st.Lead + st.Trail
+		}
+		kept = append(kept, fi)
+	}
+	return kept, res, nil
+}
@@ -0,0 +1,211 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\split.go
+// Package: app
+// Назначение: Разбиение итога на части (--split-every/--split-size): merged_part001.wav, merged_part002.wav, …
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// parseSize — размер в байтах: "2GB", "500M", "1.5g", "4096". Множители двоичные (K = 1024).
+func parseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "B"), "I") // GB, GiB → G
+	mul := 1.0
+	if n := len(v); n > 0 {
+		if i := strings.IndexByte("KMGT", v[n-1]); i >= 0 {
+			mul = math.Pow(1024, float64(i+1))
+			v = strings.TrimSpace(v[:n-1])
+		}
+	}
+	x, err := strconv.ParseFloat(v, 64)
+	if err != nil || x <= 0 || math.IsInf(x, 0) {
+		return 0, fmt.Errorf("размер %q: ожидается число с K/M/G/T (напр. 2GB)", s)
+	}
+	return int64(x * mul), nil
+}
+
+// partInfo — часть итога: [Start; End) в кадрах сквозного потока.
+type partInfo struct {
+	Path  string
+	Start int64
+	End   int64
+}
+
+// splitWriter — выход PASS2: пишет сквозной поток в одну или несколько частей.
+// Граница части ставится после merger и лимитера, поэтому кроссфейды через неё не рвутся.
+// Метки (в кадрах сквозного потока) попадают в ту часть, где начинается сегмент.
+type splitWriter struct {
+	base     string // свободный путь итога (без разбиения — сам файл); части — <имя>_part001<ext>, …
+	split    bool
+	pcm      wavPCM
+	dither   ditherMode
+	bext     func(frame int64) *bextInfo // bext части, начинающейся с кадра frame
+	every    int64                       // кадров на часть (0 — без ограничения)
+	maxBytes int64                       // размер файла части (0 — без ограничения)
+	markers  []wavMarker                 // по возрастанию Frame
+	dropped  int                         // меток за пределом 2^32 кадров части
+	cur      *wavWriter
+	ch       int64
+	pos      int64 // кадров записано всего
+	parts    []partInfo
+}
+
+// newSplitWriter выбирает имя один раз: первое из out, <имя>_1, <имя>_2, … при котором свободны
+// итог (без разбиения — сам файл, с разбиением — все части) и индекс: прежние файлы не перезаписываются.
+func newSplitWriter(out string, pcm wavPCM, dither ditherMode, every, maxBytes int64, markers []wavMarker, bext func(int64) *bextInfo) (*splitWriter, error) {
+	split := every > 0 || maxBytes > 0
+	base, err := freeOutBase(out, split)
+	if err != nil { return nil, err }
+	return &splitWriter{
+		base:     base,
+		split:    split,
+		pcm:      pcm,
+		dither:   dither,
+		bext:     bext,
+		every:    every,
+		maxBytes: maxBytes,
+		markers:  markers,
+		ch:       int64(pcm.NumChannels),
+	}, nil
+}
+
+// partPath — base + номер части: merged.wav → merged_part001.wav
+func partPath(base string, n int) string {
+	ext := filepath.Ext(base)
+	return fmt.Sprintf("%s_part%03d%s", strings.TrimSuffix(base, ext), n, ext)
+}
+
+func freeOutBase(out string, split bool) (string, error) {
+	dir := filepath.Dir(out)
+	ext := filepath.Ext(out)
+	name := strings.TrimSuffix(filepath.Base(out), ext)
+	for i := 0; i < 10000; i++ {
+		cand := filepath.Join(dir, name+ext)
+		if i > 0 { cand = filepath.Join(dir, fmt.Sprintf("%s_%d%s", name, i, ext)) }
+		free, err := outSetFree(cand, split)
+		if err != nil { return "", err }
+		if free { return cand, nil }
+	}
+	return "", fmt.Errorf("не удалось подобрать свободное имя для %s", out)
+}
+
+// outSetFree — нет ни индекса base, ни самого base (без разбиения) или ни одной части
+// base_partNNN с любым числом цифр (с разбиением).
+func outSetFree(base string, split bool) (bool, error) {
+	jsonPath, csvPath := indexPaths(base)
+	paths := []string{jsonPath, csvPath}
+	if !split { paths = append(paths, base) }
+	for _, p := range paths {
+		if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) { return false, nil }
+	}
+	if !split { return true, nil }
+	entries, err := os.ReadDir(filepath.Dir(base))
+	if errors.Is(err, os.ErrNotExist) { return true, nil }
+	if err != nil { return false, err }
+	ext := filepath.Ext(base)
+	prefix := strings.ToLower(strings.TrimSuffix(filepath.Base(base), ext) + "_part")
+	for _, e := range entries {
+		n := strings.ToLower(e.Name())
+		if !strings.HasPrefix(n, prefix) || !strings.HasSuffix(n, strings.ToLower(ext)) { continue }
+		num := n[len(prefix) : len(n)-len(ext)]
+		if num != "" && strings.Trim(num, "0123456789") == "" { return false, nil }
+	}
+	return true, nil
+}
+
+func (s *splitWriter) open() error {
+	path := s.base
+	if s.split { path = partPath(s.base, len(s.parts)+1) }
+	w, err := createWavWriter(path, s.pcm, s.bext(s.pos))
+	if err != nil { return err }
+	w.SetDither(s.dither)
+	s.cur = w
+	s.parts = append(s.parts, partInfo{Path: path, Start: s.pos, End: s.pos})
+	return nil
+}
+
+// attachMarkers переносит в текущую часть метки, чья позиция уже достигнута.
+func (s *splitWriter) attachMarkers() {
+	start := s.parts[len(s.parts)-1].Start
+	for len(s.markers) > 0 && s.markers[0].Frame <= s.pos {
+		m := s.markers[0]
+		if !s.cur.AddMarker(m.Frame-start, m.Label) { s.dropped++ }
+		s.markers = s.markers[1:]
+	}
+}
+
+// room — сколько кадров ещё помещается в текущую часть.
+func (s *splitWriter) room() int64 {
+	n := int64(math.MaxInt64)
+	if s.every > 0 { n = s.parts[len(s.parts)-1].Start + s.every - s.pos }
+	if s.maxBytes > 0 {
+		frameBytes := s.ch * int64(s.pcm.BitsPerSample/8)
+		n = min(n, (s.maxBytes-s.cur.Size()-1)/frameBytes) // -1 — байт выравнивания data
+	}
+	return n
+}
+
+func (s *splitWriter) Write(p []float32) error {
+	for len(p) > 0 {
+		if s.cur == nil {
+			if err := s.open(); err != nil { return err }
+		}
+		part := &s.parts[len(s.parts)-1]
+		// метка на границе: если с ней часть переполнится, она уходит в начало следующей
+		if s.maxBytes > 0 && s.pos > part.Start && len(s.markers) > 0 && s.markers[0].Frame <= s.pos {
+			if s.cur.Size()+markerSize(s.markers[0].Label)+s.ch*int64(s.pcm.BitsPerSample/8)+1 > s.maxBytes {
+				if err := s.closePart(); err != nil { return err }
+				continue
+			}
+		}
+		room := s.room()
+		if room <= 0 {
+			if s.pos == part.Start { return fmt.Errorf("--split-size %d байт: не помещается даже заголовок части", s.maxBytes) }
+			// часть заполнена: метка на границе уходит в начало следующей, а не в конец этой
+			if err := s.closePart(); err != nil { return err }
+			continue
+		}
+		s.attachMarkers()
+		n := min(int64(len(p))/s.ch, room)
+		if len(s.markers) > 0 { n = min(n, s.markers[0].Frame-s.pos) }
+		if n <= 0 { return fmt.Errorf("запись %d сэмплов: не кратно %d каналам", len(p), s.ch) }
+		k := n * s.ch
+		if err := s.cur.Write(p[:k]); err != nil { return err }
+		s.pos += n
+		part.End = s.pos
+		p = p[k:]
+	}
+	return nil
+}
+
+func (s *splitWriter) closePart() error {
+	err := s.cur.Close()
+	s.cur = nil
+	return err
+}
+
+// Close закрывает последнюю часть (пустой поток — одна пустая часть, как и без разбиения).
+func (s *splitWriter) Close() error {
+	if s.cur == nil && len(s.parts) == 0 {
+		if err := s.open(); err != nil { return err }
+	}
+	if s.cur == nil { return nil }
+	s.attachMarkers()
+	return s.closePart()
+}
+
+// Abort закрывает текущую часть после ошибки записи.
+func (s *splitWriter) Abort() {
+	if s.cur != nil { s.cur.Close(); s.cur = nil }
+}
+
+// Written — сэмплов (интерлив) записано во все части.
+func (s *splitWriter) Written() int64 { return s.pos * s.ch }
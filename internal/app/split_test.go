@@ -0,0 +1,165 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\split_test.go
+// Package: app
+// Назначение: Тесты разбиения: разбор --split-size, переход между частями, выбор имени итога без перезаписи прежних файлов.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	ok := map[string]int64{
+		"4096":   4096,
+		"1K":     1024,
+		"1KiB":   1024,
+		"500M":   500 << 20,
+		"2GB":    2 << 30,
+		"1.5g":   3 << 29,
+		" 1 t ":  1 << 40,
+		"0.5kb":  512,
+	}
+	for in, want := range ok {
+		got, err := parseSize(in)
+		if err != nil || got != want { t.Errorf("parseSize(%q) = %d, %v; ожидалось %d", in, got, err, want) }
+	}
+	for _, in := range []string{"", "x", "G", "0", "-1", "1X", "inf"} {
+		if _, err := parseSize(in); err == nil { t.Errorf("parseSize(%q): ожидалась ошибка", in) }
+	}
+}
+
+// writeSplit пишет samples кусками по chunk кадров и возвращает закрытый splitWriter.
+func writeSplit(t *testing.T, out string, pcm wavPCM, every, maxBytes int64, markers []wavMarker, samples []float32, chunk int) *splitWriter {
+	t.Helper()
+	w, err := newSplitWriter(out, pcm, ditherNone, every, maxBytes, markers, func(int64) *bextInfo { return nil })
+	if err != nil { t.Fatal(err) }
+	for p := samples; len(p) > 0; {
+		k := min(chunk*int(pcm.NumChannels), len(p))
+		if err := w.Write(p[:k]); err != nil { t.Fatal(err) }
+		p = p[k:]
+	}
+	if err := w.Close(); err != nil { t.Fatal(err) }
+	return w
+}
+
+// checkParts: части идут встык, покрывают весь поток, и каждая читается обратно как свой кусок samples
+// (с точностью до шага 16 бит).
+func checkParts(t *testing.T, w *splitWriter, samples []float32, ch int) {
+	t.Helper()
+	var pos int64
+	for i, p := range w.parts {
+		if p.Start != pos { t.Fatalf("часть %d: Start %d, ожидалось %d", i+1, p.Start, pos) }
+		if p.End <= p.Start { t.Fatalf("часть %d пустая: [%d; %d)", i+1, p.Start, p.End) }
+		_, got := readTestWav(t, p.Path)
+		want := samples[p.Start*int64(ch) : p.End*int64(ch)]
+		if len(got) != len(want) { t.Fatalf("часть %d: %d сэмплов, ожидалось %d", i+1, len(got), len(want)) }
+		for k := range want {
+			if math.Abs(float64(got[k]-want[k])) > 2.0/32768 { t.Fatalf("часть %d, сэмпл %d: %v != %v", i+1, k, got[k], want[k]) }
+		}
+		pos = p.End
+	}
+	if pos*int64(ch) != int64(len(samples)) || w.Written() != int64(len(samples)) {
+		t.Fatalf("записано %d кадров (Written %d), ожидалось %d сэмплов", pos, w.Written(), len(samples))
+	}
+}
+
+// cuePositions — dwPosition всех точек чанка "cue " файла.
+func cuePositions(t *testing.T, path string) []int64 {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil { t.Fatal(err) }
+	i := bytes.LastIndex(b, []byte("cue "))
+	if i < 0 { return nil }
+	n := int(binary.LittleEndian.Uint32(b[i+8:]))
+	var pos []int64
+	for k := 0; k < n; k++ { pos = append(pos, int64(binary.LittleEndian.Uint32(b[i+12+24*k+4:]))) }
+	return pos
+}
+
+func TestSplitWriterEvery(t *testing.T) {
+	pcm := pcmFormat(wavFormatFloat, 8000, 2, 32)
+	samples := testSignal(2500, 2)
+	markers := []wavMarker{{0, "a"}, {999, "b"}, {1000, "c"}, {2100, "d"}}
+	out := filepath.Join(t.TempDir(), "merged.wav")
+	w := writeSplit(t, out, pcm, 1000, 0, markers, samples, 333)
+
+	if len(w.parts) != 3 { t.Fatalf("%d частей, ожидалось 3", len(w.parts)) }
+	for i, want := range []string{"merged_part001.wav", "merged_part002.wav", "merged_part003.wav"} {
+		if filepath.Base(w.parts[i].Path) != want { t.Fatalf("часть %d: %s, ожидалось %s", i+1, filepath.Base(w.parts[i].Path), want) }
+	}
+	for i, want := range [][2]int64{{0, 1000}, {1000, 2000}, {2000, 2500}} {
+		if w.parts[i].Start != want[0] || w.parts[i].End != want[1] { t.Fatalf("часть %d: [%d; %d), ожидалось %v", i+1, w.parts[i].Start, w.parts[i].End, want) }
+	}
+	checkParts(t, w, samples, 2)
+
+	// метки — в той части, где лежит их кадр, со смещением от начала части
+	wantCues := [][]int64{{0, 999}, {0}, {100}}
+	for i, want := range wantCues {
+		got := cuePositions(t, w.parts[i].Path)
+		if len(got) != len(want) { t.Fatalf("часть %d: метки %v, ожидалось %v", i+1, got, want) }
+		for k := range want {
+			if math.Abs(float64(got[k]-want[k])) > 2.0/32768 { t.Fatalf("часть %d: метки %v, ожидалось %v", i+1, got, want) }
+		}
+	}
+}
+
+func TestSplitWriterMaxBytes(t *testing.T) {
+	pcm := pcmFormat(wavFormatPCM, 8000, 1, 16)
+	samples := testSignal(10000, 1)
+	const maxBytes = 4096
+	out := filepath.Join(t.TempDir(), "merged.wav")
+	w := writeSplit(t, out, pcm, 0, maxBytes, []wavMarker{{5000, "mid"}}, samples, 777)
+
+	if len(w.parts) < 5 { t.Fatalf("%d частей на %d байт, ожидалось не меньше 5", len(w.parts), len(samples)*2) }
+	for i, p := range w.parts {
+		st, err := os.Stat(p.Path)
+		if err != nil { t.Fatal(err) }
+		if st.Size() > maxBytes { t.Fatalf("часть %d: %d байт > %d", i+1, st.Size(), maxBytes) }
+		// все части, кроме последней, заполнены почти до предела
+		if i < len(w.parts)-1 && st.Size() < maxBytes-markerSize("mid")-64 { t.Fatalf("часть %d: %d байт — недозаполнена", i+1, st.Size()) }
+	}
+	checkParts(t, w, samples, 1)
+}
+
+func TestSplitWriterSingle(t *testing.T) {
+	pcm := pcmFormat(wavFormatPCM, 8000, 1, 16)
+	samples := testSignal(1234, 1)
+	out := filepath.Join(t.TempDir(), "merged.wav")
+	w := writeSplit(t, out, pcm, 0, 0, nil, samples, 100)
+	if len(w.parts) != 1 || w.parts[0].Path != out { t.Fatalf("части %+v, ожидался один файл %s", w.parts, out) }
+	if _, err := os.Stat(partPath(out, 1)); err == nil { t.Fatal("без разбиения создан файл части") }
+}
+
+func TestFreeOutBase(t *testing.T) {
+	cases := []struct {
+		name     string
+		existing []string
+		split    bool
+		want     string
+	}{
+		{"empty", nil, false, "merged.wav"},
+		{"wav", []string{"merged.wav"}, false, "merged_1.wav"},
+		{"stale-json", []string{"merged.index.json"}, false, "merged_1.wav"},
+		{"stale-csv", []string{"merged.index.csv", "merged_1.wav"}, false, "merged_2.wav"},
+		{"split-ignores-wav", []string{"merged.wav"}, true, "merged.wav"},
+		{"split-part", []string{"merged_part0001.wav"}, true, "merged_1.wav"},
+		{"split-index", []string{"merged.index.json"}, true, "merged_1.wav"},
+		{"split-not-a-part", []string{"merged_partial.wav", "merged_part.wav"}, true, "merged.wav"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, n := range tc.existing {
+				if err := os.WriteFile(filepath.Join(dir, n), nil, 0644); err != nil { t.Fatal(err) }
+			}
+			got, err := freeOutBase(filepath.Join(dir, "merged.wav"), tc.split)
+			if err != nil { t.Fatal(err) }
+			if filepath.Base(got) != tc.want { t.Fatalf("%s, ожидалось %s", filepath.Base(got), tc.want) }
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\timestamp.go
+// Package: app
+// Назначение: Время начала записи из имени файла по шаблону (YYYYMMDD_hhmmss_fff и т.п.).
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// nameTimeTokens — поля шаблона (они же имена групп в регулярном выражении);
+// длинные раньше коротких, чтобы YYYY не съедался YY.
+var nameTimeTokens = []struct{ tok, re string }{
+	{"YYYY", `\d{4}`},
+	{"YY", `\d{2}`},
+	{"MM", `\d{2}`},
+	{"DD", `\d{2}`},
+	{"hh", `\d{2}`},
+	{"mm", `\d{2}`},
+	{"ss", `\d{2}`},
+	{"fff", `\d{3}`},
+}
+
+// nameTimeParser ищет время в имени файла (без каталога) по шаблону.
+type nameTimeParser struct {
+	layout string
+	re     *regexp.Regexp
+}
+
+// newNameTimeParser — шаблон из полей YYYY YY MM DD hh mm ss fff; остальные символы — буквально.
+// Текст с буквами берётся в кавычки ('' — сама кавычка): поле, примыкающее к букве вне кавычек
+// (session, comm), — ошибка, а не молча найденное внутри слова поле.
+// Пример: rec_YYYYMMDD_hhmmss_fff → rec_20250101_120000_123.wav; 'session'_YYYYMMDD'T'hhmmss
+func newNameTimeParser(layout string) (*nameTimeParser, error) {
+	var sb strings.Builder
+	seen := map[string]bool{}
+	lastTok, letter := "", false // поле или буква вне кавычек перед текущей позицией
+	for rest := layout; rest != ""; {
+		if rest[0] == '\'' {
+			if strings.HasPrefix(rest, "''") {
+				sb.WriteString("'")
+				rest = rest[2:]
+			} else {
+				// текст в кавычках; '' внутри — тоже кавычка
+				var lit strings.Builder
+				for rest = rest[1:]; ; {
+					i := strings.IndexByte(rest, '\'')
+					if i < 0 { return nil, fmt.Errorf("шаблон времени %q: незакрытая кавычка", layout) }
+					lit.WriteString(rest[:i])
+					rest = rest[i+1:]
+					if !strings.HasPrefix(rest, "'") { break }
+					lit.WriteByte('\'')
+					rest = rest[1:]
+				}
+				sb.WriteString(regexp.QuoteMeta(lit.String()))
+			}
+			lastTok, letter = "", false
+			continue
+		}
+		ti := -1
+		for i, t := range nameTimeTokens {
+			if strings.HasPrefix(rest, t.tok) { ti = i; break }
+		}
+		if ti < 0 {
+			c, size := utf8.DecodeRuneInString(rest)
+			if unicode.IsLetter(c) && lastTok != "" { return nil, fieldInTextErr(layout, lastTok) }
+			sb.WriteString(regexp.QuoteMeta(rest[:size]))
+			rest = rest[size:]
+			lastTok, letter = "", unicode.IsLetter(c)
+			continue
+		}
+		t := nameTimeTokens[ti]
+		if letter { return nil, fieldInTextErr(layout, t.tok) }
+		if seen[t.tok] { return nil, fmt.Errorf("шаблон времени %q: поле %s повторяется", layout, t.tok) }
+		seen[t.tok] = true
+		sb.WriteString("(?P<" + t.tok + ">" + t.re + ")")
+		rest = rest[len(t.tok):]
+		lastTok = t.tok
+	}
+	if err := checkDateFields(layout, seen); err != nil { return nil, err }
+	re, err := regexp.Compile(sb.String())
+	if err != nil { return nil, err }
+	return &nameTimeParser{layout: layout, re: re}, nil
+}
+
+func fieldInTextErr(layout, tok string) error {
+	return fmt.Errorf("шаблон времени %q: поле %s примыкает к буквам — текст возьмите в кавычки, напр. 'session'_YYYYMMDD", layout, tok)
+}
+
+// newNameTimeRegex — регулярное выражение с именованными группами-полями:
+// (?P<YYYY>\d{4})(?P<MM>\d\d)(?P<DD>\d\d)_(?P<hh>\d\d)... ; fff — доля секунды (1–9 цифр).
+func newNameTimeRegex(expr string) (*nameTimeParser, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil { return nil, fmt.Errorf("--name-regex: %w", err) }
+	seen := map[string]bool{}
+	for _, name := range re.SubexpNames() { seen[name] = true }
+	if err := checkDateFields(expr, seen); err != nil { return nil, err }
+	return &nameTimeParser{layout: expr, re: re}, nil
+}
+
+func isNameTimeField(name string) bool {
+	for _, t := range nameTimeTokens {
+		if t.tok == name { return true }
+	}
+	return false
+}
+
+func checkDateFields(spec string, seen map[string]bool) error {
+	if !(seen["YYYY"] || seen["YY"]) || !seen["MM"] || !seen["DD"] {
+		return fmt.Errorf("шаблон времени %q: нужны как минимум год, месяц и день (YYYY/YY, MM, DD)", spec)
+	}
+	return nil
+}
+
+// Parse возвращает время из имени; ok=false, если имя не подходит под шаблон.
+func (p *nameTimeParser) Parse(path string) (time.Time, bool) {
+	m := p.re.FindStringSubmatch(filepath.Base(path))
+	if m == nil { return time.Time{}, false }
+	v := map[string]int{}
+	nsec := 0
+	for i, name := range p.re.SubexpNames() {
+		if !isNameTimeField(name) || m[i] == "" { continue }
+		n, err := strconv.Atoi(m[i])
+		if err != nil { return time.Time{}, false }
+		if name == "fff" { // доля секунды: "5" = 500 мс, "123456" = 123.456 мс
+			if len(m[i]) > 9 { return time.Time{}, false }
+			for k := len(m[i]); k < 9; k++ { n *= 10 }
+			nsec = n
+		}
+		v[name] = n
+	}
+	year := v["YYYY"]
+	if _, ok := v["YY"]; ok { year = 2000 + v["YY"] }
+	t := time.Date(year, time.Month(v["MM"]), v["DD"], v["hh"], v["mm"], v["ss"], nsec, time.Local)
+	// time.Date нормализует 31.02 → 03.03; такие имена считаем несовпавшими
+	if t.Month() != time.Month(v["MM"]) || t.Day() != v["DD"] || v["hh"] > 23 || v["mm"] > 59 || v["ss"] > 59 {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,65 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\timestamp_test.go
+// Package: app
+// Назначение: Тесты времени из имени файла: шаблон --name-time (поля, кавычки) и --name-regex.
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNameTimeParse(t *testing.T) {
+	at := func(y, mo, d, h, mi, s, ms int) time.Time {
+		return time.Date(y, time.Month(mo), d, h, mi, s, ms*int(time.Millisecond), time.Local)
+	}
+	cases := []struct {
+		layout string
+		name   string
+		want   time.Time // zero — имя не подходит
+	}{
+		{"rec_YYYYMMDD_hhmmss_fff", "rec_20250101_120000_123.wav", at(2025, 1, 1, 12, 0, 0, 123)},
+		{"rec_YYYYMMDD_hhmmss_fff", "D:/Raw/rec_20250101_120000_123.wav", at(2025, 1, 1, 12, 0, 0, 123)},
+		{"YYMMDD-hhmm", "x_250315-0930.wav", at(2025, 3, 15, 9, 30, 0, 0)},
+		{"'session'_YYYYMMDD_hhmmss", "session_20250101_235959.wav", at(2025, 1, 1, 23, 59, 59, 0)},
+		{"'comm'_YYYYMMDD", "comm_20250102.wav", at(2025, 1, 2, 0, 0, 0, 0)},
+		{"YYYYMMDD'T'hhmmss", "20250101T101112.wav", at(2025, 1, 1, 10, 11, 12, 0)},
+		{"'it''s'_YYYY-MM-DD", "it's_2025-06-30.wav", at(2025, 6, 30, 0, 0, 0, 0)},
+		{"'a.b'_YYYYMMDD", "axb_20250101.wav", time.Time{}}, // текст в кавычках — буквально, точка не «любой символ»
+		{"rec_YYYYMMDD_hhmmss_fff", "rec_20250101_120000.wav", time.Time{}},
+		{"rec_YYYYMMDD", "rec_20250231.wav", time.Time{}}, // 31 февраля
+		{"rec_YYYYMMDD_hhmm", "rec_20250101_2460.wav", time.Time{}},
+	}
+	for _, tc := range cases {
+		p, err := newNameTimeParser(tc.layout)
+		if err != nil { t.Fatalf("%q: %v", tc.layout, err) }
+		got, ok := p.Parse(tc.name)
+		if ok != !tc.want.IsZero() || !got.Equal(tc.want) {
+			t.Fatalf("%q на %q: %v (ok=%v), ожидалось %v", tc.layout, tc.name, got, ok, tc.want)
+		}
+	}
+}
+
+func TestNameTimeLayoutErrors(t *testing.T) {
+	for _, layout := range []string{
+		"session_YYYYMMDD_hhmmss", // ss внутри слова
+		"comm_YYYYMMDD",           // mm внутри слова
+		"YYYYMMDDThhmmss",         // буква вплотную к полям
+		"rec_YYYYMMDDx",
+		"'rec_YYYYMMDD",           // незакрытая кавычка
+		"YYYYMMDD_MM",             // поле дважды
+		"rec_hhmmss",              // нет даты
+	} {
+		if _, err := newNameTimeParser(layout); err == nil { t.Fatalf("%q: ожидалась ошибка", layout) }
+	}
+}
+
+func TestNameTimeRegex(t *testing.T) {
+	p, err := newNameTimeRegex(`(?P<YYYY>\d{4})-(?P<MM>\d\d)-(?P<DD>\d\d)T(?P<hh>\d\d)(?P<mm>\d\d)(?P<ss>\d\d)\.(?P<fff>\d+)`)
+	if err != nil { t.Fatal(err) }
+	got, ok := p.Parse("cam_2025-01-01T120000.5.wav")
+	if want := time.Date(2025, 1, 1, 12, 0, 0, 500*int(time.Millisecond), time.Local); !ok || !got.Equal(want) {
+		t.Fatalf("%v (ok=%v), ожидалось %v", got, ok, want)
+	}
+	if _, err := newNameTimeRegex(`(?P<hh>\d\d)`); err == nil { t.Fatalf("регулярное выражение без даты: ожидалась ошибка") }
+}
@@ -0,0 +1,647 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\wav.go
+// Package: app
+// Назначение: Потоковый WAV/RF64 I/O (PCM 8/16/24/32 бит, IEEE float 32/64, WAVE_FORMAT_EXTENSIBLE, bext): чтение заголовка и данных порциями, запись через фиксированный буфер.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3      // WAVE_FORMAT_IEEE_FLOAT
+	wavFormatExtensible = 0xFFFE // WAVE_FORMAT_EXTENSIBLE
+)
+
+// ksSubFormatTail — общая часть GUID KSDATAFORMAT_SUBTYPE_* (после 2 байт кода формата).
+var ksSubFormatTail = []byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+// wavPCM — описание формата из fmt. Для WAVE_FORMAT_EXTENSIBLE AudioFormat уже содержит
+// код из SubFormat (PCM/float), а ValidBits/ChannelMask — поля расширения.
+type wavPCM struct {
+	AudioFormat   uint16
+	NumChannels   uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+	ValidBits     uint16 // значащих бит в контейнере (0 = все)
+	ChannelMask   uint32 // раскладка каналов (0 = не задана)
+}
+
+// extensible — нужен ли fmt в виде WAVE_FORMAT_EXTENSIBLE, чтобы не потерять ValidBits/ChannelMask.
+func (p wavPCM) extensible() bool {
+	return p.ChannelMask != 0 || (p.ValidBits != 0 && p.ValidBits != p.BitsPerSample)
+}
+
+// validBits — значащих бит (с учётом wValidBitsPerSample).
+func (p wavPCM) validBits() int {
+	if p.ValidBits != 0 { return int(p.ValidBits) }
+	return int(p.BitsPerSample)
+}
+
+func parseFmt(buf []byte) (wavPCM, error) {
+	if len(buf) < 16 { return wavPCM{}, errors.New("короткий fmt chunk") }
+	le := binary.LittleEndian
+	p := wavPCM{
+		AudioFormat:   le.Uint16(buf[0:]),
+		NumChannels:   le.Uint16(buf[2:]),
+		SampleRate:    le.Uint32(buf[4:]),
+		ByteRate:      le.Uint32(buf[8:]),
+		BlockAlign:    le.Uint16(buf[12:]),
+		BitsPerSample: le.Uint16(buf[14:]),
+	}
+	if p.AudioFormat != wavFormatExtensible { return p, nil }
+
+	// cbSize, wValidBitsPerSample, dwChannelMask, SubFormat
+	if len(buf) < 40 || le.Uint16(buf[16:]) < 22 { return p, errors.New("WAVE_FORMAT_EXTENSIBLE: короткое расширение fmt") }
+	p.ValidBits = le.Uint16(buf[18:])
+	p.ChannelMask = le.Uint32(buf[20:])
+	guid := buf[24:40]
+	if !bytes.Equal(guid[2:], ksSubFormatTail) {
+		return p, fmt.Errorf("WAVE_FORMAT_EXTENSIBLE: неизвестный SubFormat %X", guid)
+	}
+	p.AudioFormat = le.Uint16(guid)
+	if p.ValidBits > p.BitsPerSample {
+		return p, fmt.Errorf("WAVE_FORMAT_EXTENSIBLE: valid bits %d > %d", p.ValidBits, p.BitsPerSample)
+	}
+	return p, nil
+}
+
+// fmtChunk — тело fmt: 16 байт для PCM, 18 для float (cbSize=0), 40 для EXTENSIBLE.
+func fmtChunk(p wavPCM) []byte {
+	le := binary.LittleEndian
+	tag := p.AudioFormat
+	if p.extensible() { tag = wavFormatExtensible }
+	b := make([]byte, 0, 40)
+	b = le.AppendUint16(b, tag)
+	b = le.AppendUint16(b, p.NumChannels)
+	b = le.AppendUint32(b, p.SampleRate)
+	b = le.AppendUint32(b, p.ByteRate)
+	b = le.AppendUint16(b, p.BlockAlign)
+	b = le.AppendUint16(b, p.BitsPerSample)
+	switch {
+	case p.extensible():
+		b = le.AppendUint16(b, 22)
+		b = le.AppendUint16(b, uint16(p.validBits()))
+		b = le.AppendUint32(b, p.ChannelMask)
+		b = le.AppendUint16(b, p.AudioFormat)
+		b = append(b, ksSubFormatTail...)
+	case p.AudioFormat != wavFormatPCM:
+		b = le.AppendUint16(b, 0)
+	}
+	return b
+}
+
+// pcmFormat — заполняет производные поля (ByteRate/BlockAlign) по частоте, каналам и разрядности.
+func pcmFormat(audioFormat uint16, sampleRate uint32, channels, bits uint16) wavPCM {
+	block := channels * (bits / 8)
+	return wavPCM{
+		AudioFormat:   audioFormat,
+		NumChannels:   channels,
+		SampleRate:    sampleRate,
+		ByteRate:      sampleRate * uint32(block),
+		BlockAlign:    block,
+		BitsPerSample: bits,
+	}
+}
+
+func supportedPCMBits(bits int) bool {
+	return bits == 8 || bits == 16 || bits == 24 || bits == 32
+}
+
+// checkFormat — поддерживается ли кодирование сэмплов (PCM 8/16/24/32 или float 32/64).
+func checkFormat(pcm wavPCM) error {
+	switch pcm.AudioFormat {
+	case wavFormatPCM:
+		if !supportedPCMBits(int(pcm.BitsPerSample)) {
+			return fmt.Errorf("поддерживается PCM 8/16/24/32 бит, найдено: %d", pcm.BitsPerSample)
+		}
+	case wavFormatFloat:
+		if pcm.BitsPerSample != 32 && pcm.BitsPerSample != 64 {
+			return fmt.Errorf("поддерживается float 32/64 бит, найдено: %d", pcm.BitsPerSample)
+		}
+	default:
+		return fmt.Errorf("неподдерживаемый формат: fmt=%d (нужен PCM=1 или IEEE float=3)", pcm.AudioFormat)
+	}
+	return nil
+}
+
+func describeFormat(pcm wavPCM) string {
+	s := fmt.Sprintf("PCM %d bit", pcm.BitsPerSample)
+	if pcm.AudioFormat == wavFormatFloat { s = fmt.Sprintf("float %d bit", pcm.BitsPerSample) }
+	if pcm.validBits() != int(pcm.BitsPerSample) { s += fmt.Sprintf(" (%d valid)", pcm.validBits()) }
+	if pcm.ChannelMask != 0 { s += fmt.Sprintf(", mask 0x%X", pcm.ChannelMask) }
+	return s
+}
+
+// ---------- чтение ----------
+
+// wavReader — открытый WAV, позиционированный на начало data; сэмплы отдаются порциями в float32.
+type wavReader struct {
+	f        *os.File
+	br       *bufio.Reader
+	PCM      wavPCM
+	Bext     *bextInfo // Broadcast WAV (если есть)
+	left     int64     // байт data осталось (только целые кадры)
+	dataOff  int64     // смещение аудиоданных в файле
+	Repaired string    // что исправлено в режиме repair (пусто — заголовок верен)
+	raw      []byte
+}
+
+// openWav открывает WAV и разбирает заголовок. repair (--repair): если размер data нулевой или
+// больше файла (запись оборвалась до финализации заголовка), data длится до чанков метаданных
+// в конце файла (LIST, id3, cue, …), а если их нет — до конца файла.
+func openWav(path string, repair bool) (*wavReader, error) {
+	f, err := os.Open(path)
+	if err != nil { return nil, err }
+	r := &wavReader{f: f, br: bufio.NewReaderSize(f, 64*1024)}
+	if err := r.readHeader(repair); err != nil { f.Close(); return nil, err }
+	return r, nil
+}
+
+// wavHeader — сведения из заголовка без чтения данных.
+type wavHeader struct {
+	PCM    wavPCM
+	Frames int64
+	Bext   *bextInfo
+}
+
+// probeWav — только заголовок (для проверки формата).
+func probeWav(path string, repair bool) (wavPCM, error) {
+	h, err := probeWavHeader(path, repair)
+	return h.PCM, err
+}
+
+func probeWavHeader(path string, repair bool) (wavHeader, error) {
+	r, err := openWav(path, repair)
+	if err != nil { return wavHeader{}, err }
+	defer r.Close()
+	return wavHeader{PCM: r.PCM, Frames: r.Samples() / int64(r.PCM.NumChannels), Bext: r.Bext}, nil
+}
+
+func (r *wavReader) Close() error { return r.f.Close() }
+
+// Samples — число сэмплов (интерлив) в data.
+func (r *wavReader) Samples() int64 {
+	return r.left / int64(r.PCM.BitsPerSample/8)
+}
+
+func (r *wavReader) bytesPerSample() int { return int(r.PCM.BitsPerSample / 8) }
+
+func (r *wavReader) readHeader(repair bool) error {
+	br := r.br
+
+	// RIFF (или RF64/BW64 — размеры больше 4 GiB берутся из ds64)
+	var riff [4]byte
+	if _, err := io.ReadFull(br, riff[:]); err != nil { return err }
+	rf64 := string(riff[:]) == "RF64" || string(riff[:]) == "BW64"
+	if string(riff[:]) != "RIFF" && !rf64 { return errors.New("не RIFF/RF64") }
+	// Skip ChunkSize
+	if _, err := br.Discard(4); err != nil { return err }
+	var wave [4]byte
+	if _, err := io.ReadFull(br, wave[:]); err != nil { return err }
+	if string(wave[:]) != "WAVE" { return errors.New("не WAVE") }
+
+	pcm := &r.PCM
+	var dataSize64 int64 = -1
+	pos := int64(12) // смещение текущего чанка
+	for {
+		var id [4]byte
+		if _, err := io.ReadFull(br, id[:]); err != nil {
+			if errors.Is(err, io.EOF) { break }
+			return err
+		}
+		var size uint32
+		if err := binary.Read(br, binary.LittleEndian, &size); err != nil { return err }
+
+		switch string(id[:]) {
+		case "bext":
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(br, buf); err != nil { return err }
+			b, err := parseBext(buf)
+			if err != nil { return err }
+			r.Bext = b
+		case "ds64":
+			if size < 24 { return errors.New("RF64: короткий ds64") }
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(br, buf); err != nil { return err }
+			dataSize64 = int64(binary.LittleEndian.Uint64(buf[8:]))
+		case "fmt ":
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(br, buf); err != nil { return err }
+			p, err := parseFmt(buf)
+			if err != nil { return err }
+			*pcm = p
+		case "data":
+			if pcm.AudioFormat == 0 { return errors.New("встретили data до fmt") }
+			if err := checkFormat(*pcm); err != nil { return err }
+			if pcm.NumChannels == 0 { return errors.New("некорректный fmt: 0 каналов") }
+			if pcm.SampleRate == 0 { return errors.New("некорректный fmt: частота 0 Гц") }
+			block := int64(pcm.NumChannels) * int64(pcm.BitsPerSample/8)
+			n := int64(size)
+			if rf64 && size == riffMaxSize {
+				if dataSize64 < 0 && !repair { return errors.New("RF64: data без ds64") }
+				n = dataSize64
+			}
+			tail := int64(-1) // начало чанков после data, если найдено при repair
+			if repair {
+				st, err := r.f.Stat()
+				if err != nil { return err }
+				if avail := st.Size() - (pos + 8); n <= 0 || n > avail {
+					// после оборванной data могут идти дописанные позже LIST/id3/cue — они не аудио
+					if k, id := trailingChunks(r.f, pos+8, st.Size()); id != "" {
+						r.Repaired = fmt.Sprintf("data %d байт, в файле %d — длина до чанка %q: %d байт аудио", n, avail, id, k-(pos+8))
+						n, tail = k-(pos+8), k
+					} else {
+						r.Repaired = fmt.Sprintf("data %d байт, в файле %d — длина по размеру файла: %d байт аудио", n, avail, max(avail, 0))
+						n = max(avail, 0)
+					}
+				}
+			}
+			r.left = n / block * block
+			r.dataOff = pos + 8
+			if r.left == 0 { return errors.New("нет аудио-данных (data chunk)") }
+			if tail < 0 { tail = pos + 8 + n + n%2 }
+			if r.Bext == nil { r.scanTrailing(tail) }
+			return nil
+		default:
+			if _, err := br.Discard(int(size)); err != nil { return err }
+		}
+		// выравнивание
+		if size%2 == 1 {
+			if _, err := br.Discard(1); err != nil { return err }
+		}
+		pos += 8 + int64(size) + int64(size%2)
+	}
+	return errors.New("нет аудио-данных (data chunk)")
+}
+
+// scanTrailing — ищет метаданные (bext) в чанках после data, не сдвигая позицию чтения.
+// Ошибки игнорируются: хвост файла необязателен для склейки.
+func (r *wavReader) scanTrailing(off int64) {
+	var hdr [8]byte
+	for {
+		if _, err := r.f.ReadAt(hdr[:], off); err != nil { return }
+		size := int64(binary.LittleEndian.Uint32(hdr[4:]))
+		if string(hdr[:4]) == "bext" {
+			buf := make([]byte, size)
+			if _, err := r.f.ReadAt(buf, off+8); err != nil { return }
+			if b, err := parseBext(buf); err == nil { r.Bext = b }
+			return
+		}
+		off += 8 + size + size%2
+	}
+}
+
+// trailingIDs — чанки, которые пишут после data (метаданные редакторов и рекордеров).
+var trailingIDs = map[string]bool{
+	"LIST": true, "id3 ": true, "ID3 ": true, "cue ": true, "bext": true, "iXML": true, "axml": true,
+	"smpl": true, "inst": true, "acid": true, "_PMX": true, "umid": true, "JUNK": true, "PEAK": true,
+}
+
+// trailingTail — сколько байт с конца файла просматривает trailingChunks.
+const trailingTail = 1 << 20
+
+// trailingChunks ищет в хвосте [start; end) файла первый чанк из trailingIDs, с которого цепочка
+// чанков (ID из печатных ASCII, размеры в пределах файла) доходит ровно до конца файла.
+// Возвращает его смещение и ID; "" — таких чанков нет, data идёт до конца файла.
+func trailingChunks(f *os.File, start, end int64) (int64, string) {
+	from := max(start, end-trailingTail)
+	buf := make([]byte, end-from)
+	if _, err := f.ReadAt(buf, from); err != nil { return 0, "" }
+	for i := 0; i+8 <= len(buf); i++ {
+		if id := string(buf[i : i+4]); trailingIDs[id] && chunkChain(buf[i:]) { return from + int64(i), id }
+	}
+	return 0, ""
+}
+
+// chunkChain — b целиком состоит из чанков; у последнего допускается недописанный байт выравнивания.
+func chunkChain(b []byte) bool {
+	for len(b) > 0 {
+		if len(b) < 8 { return false }
+		for _, c := range b[:4] {
+			if c < 0x20 || c > 0x7e { return false }
+		}
+		size := int64(binary.LittleEndian.Uint32(b[4:8]))
+		next := 8 + size + size%2
+		switch {
+		case next == int64(len(b)) || (size%2 == 1 && next-1 == int64(len(b))):
+			return true
+		case next > int64(len(b)):
+			return false
+		}
+		b = b[next:]
+	}
+	return false
+}
+
+// Read декодирует до len(dst) сэмплов в [-1; 1). В конце data возвращает io.EOF.
+func (r *wavReader) Read(dst []float32) (int, error) {
+	if r.left <= 0 { return 0, io.EOF }
+	bps := r.bytesPerSample()
+	n := len(dst)
+	if int64(n*bps) > r.left { n = int(r.left / int64(bps)) }
+	need := n * bps
+	if cap(r.raw) < need { r.raw = make([]byte, need) }
+	raw := r.raw[:need]
+	if _, err := io.ReadFull(r.br, raw); err != nil {
+		if errors.Is(err, io.EOF) { err = io.ErrUnexpectedEOF }
+		return 0, err
+	}
+	r.left -= int64(need)
+	if r.PCM.AudioFormat == wavFormatFloat {
+		decodeFloat(dst[:n], raw, bps)
+	} else {
+		decodePCM(dst[:n], raw, bps, bps*8-r.PCM.validBits())
+	}
+	return n, nil
+}
+
+// Skip пропускает samples сэмплов data без декодирования (seek).
+func (r *wavReader) Skip(samples int64) error {
+	n := samples * int64(r.bytesPerSample())
+	if n > r.left { n = r.left }
+	pos, err := r.f.Seek(0, io.SeekCurrent)
+	if err != nil { return err }
+	pos -= int64(r.br.Buffered())
+	if _, err := r.f.Seek(pos+n, io.SeekStart); err != nil { return err }
+	r.br.Reset(r.f)
+	r.left -= n
+	return nil
+}
+
+// decodePCM — целочисленный PCM (little-endian; 8 бит — беззнаковый) → float32 в [-1; 1).
+// pad — младшие биты контейнера сверх wValidBitsPerSample; они обнуляются.
+func decodePCM(dst []float32, raw []byte, bps, pad int) {
+	switch bps {
+	case 1:
+		const s = 1.0 / 128.0
+		for i := range dst { dst[i] = float32(int(raw[i])-128) * s }
+	case 2:
+		const s = 1.0 / 32768.0
+		for i := range dst {
+			v := int16(binary.LittleEndian.Uint16(raw[2*i:])) >> pad << pad
+			dst[i] = float32(v) * s
+		}
+	case 3:
+		const s = 1.0 / 8388608.0
+		for i := range dst {
+			b := raw[3*i:]
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> (8 + pad) << pad
+			dst[i] = float32(float64(v) * s)
+		}
+	case 4:
+		const s = 1.0 / 2147483648.0
+		for i := range dst {
+			v := int32(binary.LittleEndian.Uint32(raw[4*i:])) >> pad << pad
+			dst[i] = float32(float64(v) * s)
+		}
+	}
+}
+
+// decodeFloat — IEEE float 32/64 (little-endian) → float32 без клиппирования.
+func decodeFloat(dst []float32, raw []byte, bps int) {
+	if bps == 8 {
+		for i := range dst { dst[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(raw[8*i:]))) }
+		return
+	}
+	for i := range dst { dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:])) }
+}
+
+// ---------- запись ----------
+
+// wavWriter — PCM/float-вывод; сэмплы принимаются в float32 (для PCM клиппируются в [-1; 1]).
+// Размеры в заголовке проставляются в Close по факту записанного; если файл вышел за предел
+// RIFF (4 GiB), заголовок переписывается в RF64: зарезервированный JUNK становится ds64.
+type wavWriter struct {
+	f       *os.File
+	bw      *bufio.Writer
+	pcm     wavPCM
+	raw     []byte
+	written int64 // сэмплов записано
+	junkOff int64 // смещение JUNK-резерва под ds64
+	factOff int64 // смещение fact (0 = нет)
+	dataOff int64 // смещение заголовка data
+	markers []wavMarker
+	dither  *ditherer // nil = без дизеринга
+	rf64At  int64     // порог перехода на RF64, байт (riffMaxSize; в тестах — меньше)
+}
+
+// wavMarker — метка (cue + labl) в кадрах от начала data.
+type wavMarker struct {
+	Frame int64
+	Label string
+}
+
+const (
+	riffMaxSize = 0xFFFFFFFF
+	ds64Size    = 28 // riffSize64 + dataSize64 + sampleCount64 + tableLength
+)
+
+// createWavWriter создаёт вывод; bext (может быть nil) пишется перед fmt.
+func createWavWriter(path string, pcm wavPCM, bext *bextInfo) (*wavWriter, error) {
+	f, err := os.Create(path)
+	if err != nil { return nil, err }
+	w := &wavWriter{f: f, bw: bufio.NewWriterSize(f, 256*1024), pcm: pcm, rf64At: riffMaxSize}
+	if err := w.writeHeader(bext); err != nil { f.Close(); return nil, err }
+	return w, nil
+}
+
+func (w *wavWriter) writeHeader(bext *bextInfo) error {
+	le := binary.LittleEndian
+	fmtBody := fmtChunk(w.pcm)
+	// для всего, кроме простого PCM, обязателен fact (число кадров)
+	needFact := w.pcm.AudioFormat != wavFormatPCM || w.pcm.extensible()
+
+	// RIFF/WAVE (размер — заглушка до Close)
+	h := make([]byte, 0, 128)
+	h = append(h, "RIFF"...)
+	h = le.AppendUint32(h, 0)
+	h = append(h, "WAVE"...)
+
+	// JUNK — резерв под ds64 на случай RF64
+	w.junkOff = int64(len(h))
+	h = append(h, "JUNK"...)
+	h = le.AppendUint32(h, ds64Size)
+	h = append(h, make([]byte, ds64Size)...)
+
+	// bext
+	if bext != nil {
+		body := bextChunk(bext)
+		h = append(h, "bext"...)
+		h = le.AppendUint32(h, uint32(len(body)))
+		h = append(h, body...)
+	}
+
+	// fmt
+	h = append(h, "fmt "...)
+	h = le.AppendUint32(h, uint32(len(fmtBody)))
+	h = append(h, fmtBody...)
+	if needFact {
+		w.factOff = int64(len(h))
+		h = append(h, "fact"...)
+		h = le.AppendUint32(h, 4)
+		h = le.AppendUint32(h, 0)
+	}
+
+	// data
+	w.dataOff = int64(len(h))
+	h = append(h, "data"...)
+	h = le.AppendUint32(h, 0)
+	_, err := w.bw.Write(h)
+	return err
+}
+
+// Write кодирует порцию сэмплов в выходной формат через переиспользуемый буфер.
+func (w *wavWriter) Write(f []float32) error {
+	if len(f) == 0 { return nil }
+	bps := int(w.pcm.BitsPerSample / 8)
+	need := len(f) * bps
+	if cap(w.raw) < need { w.raw = make([]byte, need) }
+	raw := w.raw[:need]
+	if w.pcm.AudioFormat == wavFormatFloat {
+		for i, v := range f { binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(v)) }
+	} else {
+		if w.dither != nil { f = w.dither.Apply(f) }
+		encodePCM(raw, f, bps, bps*8-w.pcm.validBits())
+	}
+	if _, err := w.bw.Write(raw); err != nil { return err }
+	w.written += int64(len(f))
+	return nil
+}
+
+// SetDither включает дизеринг при квантовании в целочисленный PCM (для float — без эффекта).
+func (w *wavWriter) SetDither(mode ditherMode) {
+	if mode == ditherNone || w.pcm.AudioFormat == wavFormatFloat { return }
+	w.dither = newDitherer(mode, int(w.pcm.NumChannels), w.pcm.validBits())
+}
+
+// AddMarker добавляет метку; cue/LIST-adtl пишутся после data в Close.
+// Позиция в cue 32-битная, поэтому метки дальше 2^32 кадров отбрасываются.
+func (w *wavWriter) AddMarker(frame int64, label string) bool {
+	if frame < 0 || frame > math.MaxUint32 { return false }
+	w.markers = append(w.markers, wavMarker{Frame: frame, Label: label})
+	return true
+}
+
+// Size — размер файла, если закрыть его сейчас: заголовки, data с выравниванием и метки.
+func (w *wavWriter) Size() int64 {
+	data := w.written * int64(w.pcm.BitsPerSample/8)
+	n := w.dataOff + 8 + data + data%2
+	if len(w.markers) > 0 {
+		n += 12 + 12 // "cue " + счётчик, "LIST" + "adtl"
+		for _, m := range w.markers { n += markerSize(m.Label) }
+	}
+	return n
+}
+
+// markerSize — байт на одну метку: точка cue и чанк labl с выравниванием.
+func markerSize(label string) int64 {
+	t := int64(len(label)) + 1
+	return 24 + 12 + t + t%2
+}
+
+// writeMarkers — чанк "cue " и LIST/adtl с подписями (labl) к каждой точке.
+func (w *wavWriter) writeMarkers() error {
+	if len(w.markers) == 0 { return nil }
+	le := binary.LittleEndian
+	cue := make([]byte, 0, 12+24*len(w.markers))
+	cue = append(cue, "cue "...)
+	cue = le.AppendUint32(cue, uint32(4+24*len(w.markers)))
+	cue = le.AppendUint32(cue, uint32(len(w.markers)))
+	for i, m := range w.markers {
+		cue = le.AppendUint32(cue, uint32(i+1))    // dwName
+		cue = le.AppendUint32(cue, uint32(m.Frame)) // dwPosition
+		cue = append(cue, "data"...)
+		cue = le.AppendUint32(cue, 0) // dwChunkStart
+		cue = le.AppendUint32(cue, 0) // dwBlockStart
+		cue = le.AppendUint32(cue, uint32(m.Frame))
+	}
+	if _, err := w.bw.Write(cue); err != nil { return err }
+
+	adtl := []byte("adtl")
+	for i, m := range w.markers {
+		text := append([]byte(m.Label), 0)
+		adtl = append(adtl, "labl"...)
+		adtl = le.AppendUint32(adtl, uint32(4+len(text)))
+		adtl = le.AppendUint32(adtl, uint32(i+1))
+		adtl = append(adtl, text...)
+		if len(text)%2 == 1 { adtl = append(adtl, 0) }
+	}
+	list := append([]byte("LIST"), le.AppendUint32(nil, uint32(len(adtl)))...)
+	if _, err := w.bw.Write(append(list, adtl...)); err != nil { return err }
+	return nil
+}
+
+// Close дописывает выравнивание data, метки и проставляет размеры (RIFF или RF64/ds64).
+func (w *wavWriter) Close() error {
+	if err := w.finish(); err != nil { w.f.Close(); return err }
+	return w.f.Close()
+}
+
+func (w *wavWriter) finish() error {
+	le := binary.LittleEndian
+	dataSize := w.written * int64(w.pcm.BitsPerSample/8)
+	if dataSize%2 == 1 {
+		if err := w.bw.WriteByte(0); err != nil { return err }
+	}
+	if err := w.writeMarkers(); err != nil { return err }
+	if err := w.bw.Flush(); err != nil { return err }
+	end, err := w.f.Seek(0, io.SeekCurrent)
+	if err != nil { return err }
+
+	riffSize := end - 8
+	frames := w.written / int64(w.pcm.NumChannels)
+	rf64 := riffSize > w.rf64At || dataSize > w.rf64At
+	size32 := func(v int64) []byte {
+		if rf64 { return le.AppendUint32(nil, riffMaxSize) }
+		return le.AppendUint32(nil, uint32(v))
+	}
+
+	if rf64 {
+		if _, err := w.f.WriteAt([]byte("RF64"), 0); err != nil { return err }
+		ds := append([]byte("ds64"), le.AppendUint32(nil, ds64Size)...)
+		ds = le.AppendUint64(ds, uint64(riffSize))
+		ds = le.AppendUint64(ds, uint64(dataSize))
+		ds = le.AppendUint64(ds, uint64(frames))
+		ds = le.AppendUint32(ds, 0) // таблица размеров прочих чанков не нужна
+		if _, err := w.f.WriteAt(ds, w.junkOff); err != nil { return err }
+	}
+	if _, err := w.f.WriteAt(size32(riffSize), 4); err != nil { return err }
+	if w.factOff > 0 {
+		if _, err := w.f.WriteAt(size32(frames), w.factOff+8); err != nil { return err }
+	}
+	if _, err := w.f.WriteAt(size32(dataSize), w.dataOff+4); err != nil { return err }
+	return nil
+}
+
+// encodePCM — float32 → целочисленный PCM с клиппированием в [-1; 1].
+// pad — младшие биты контейнера сверх значащих: квантование идёт по значащим битам.
+func encodePCM(raw []byte, f []float32, bps, pad int) {
+	fs := math.Ldexp(1, bps*8-1-pad) - 1
+	for i, v := range f {
+		x := float64(v)
+		if x > 1.0 { x = 1.0 }
+		if x < -1.0 { x = -1.0 }
+		q := int32(math.Round(x*fs)) << pad
+		switch bps {
+		case 1:
+			raw[i] = byte(q + 128)
+		case 2:
+			binary.LittleEndian.PutUint16(raw[2*i:], uint16(int16(q)))
+		case 3:
+			raw[3*i], raw[3*i+1], raw[3*i+2] = byte(q), byte(q>>8), byte(q>>16)
+		case 4:
+			binary.LittleEndian.PutUint32(raw[4*i:], uint32(q))
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\wav_test.go
+// Package: app
+// Назначение: Тесты WAV: запись → чтение (PCM 8/16/24/32, float 32), заголовок RF64/ds64, отказ на некорректном fmt,
+// --repair оборванной data с чанками метаданных в конце.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testSignal — детерминированный сигнал в (-0.9; 0.9), каналы различаются.
+func testSignal(frames, ch int) []float32 {
+	f := make([]float32, frames*ch)
+	for i := 0; i < frames; i++ {
+		for c := 0; c < ch; c++ {
+			f[i*ch+c] = float32(0.9 * math.Sin(float64(i)*0.05*float64(c+1)+float64(c)))
+		}
+	}
+	return f
+}
+
+// writeTestWav пишет samples; tweak (если задан) вызывается до Close.
+func writeTestWav(t *testing.T, path string, pcm wavPCM, samples []float32, tweak func(w *wavWriter)) {
+	t.Helper()
+	w, err := createWavWriter(path, pcm, nil)
+	if err != nil { t.Fatal(err) }
+	if err := w.Write(samples); err != nil { t.Fatal(err) }
+	if tweak != nil { tweak(w) }
+	if err := w.Close(); err != nil { t.Fatal(err) }
+}
+
+func readTestWav(t *testing.T, path string) (*wavReader, []float32) {
+	t.Helper()
+	r, err := openWav(path, false)
+	if err != nil { t.Fatal(err) }
+	t.Cleanup(func() { r.Close() })
+	out := make([]float32, r.Samples())
+	for got := 0; got < len(out); {
+		n, err := r.Read(out[got:])
+		if err != nil { t.Fatalf("read at %d: %v", got, err) }
+		got += n
+	}
+	if n, err := r.Read(make([]float32, 16)); n != 0 || err == nil { t.Fatalf("после data: n=%d err=%v, ожидался EOF", n, err) }
+	return r, out
+}
+
+func TestWavRoundTrip(t *testing.T) {
+	cases := []struct {
+		name   string
+		format uint16
+		bits   uint16
+	}{
+		{"pcm8", wavFormatPCM, 8},
+		{"pcm16", wavFormatPCM, 16},
+		{"pcm24", wavFormatPCM, 24},
+		{"pcm32", wavFormatPCM, 32},
+		{"float32", wavFormatFloat, 32},
+	}
+	for _, tc := range cases {
+		for _, ch := range []int{1, 2} {
+			t.Run(fmt.Sprintf("%s/%dch", tc.name, ch), func(t *testing.T) {
+				const frames = 1001 // нечётно: для 8 бит моно проверяется байт выравнивания data
+				path := filepath.Join(t.TempDir(), "x.wav")
+				pcm := pcmFormat(tc.format, 16000, uint16(ch), tc.bits)
+				in := testSignal(frames, ch)
+				writeTestWav(t, path, pcm, in, nil)
+
+				raw, err := os.ReadFile(path)
+				if err != nil { t.Fatal(err) }
+				if string(raw[:4]) != "RIFF" || string(raw[12:16]) != "JUNK" {
+					t.Fatalf("заголовок %q…%q, ожидались RIFF и JUNK", raw[:4], raw[12:16])
+				}
+				if got := binary.LittleEndian.Uint32(raw[4:]); int(got) != len(raw)-8 {
+					t.Fatalf("RIFF size %d, файл %d байт", got, len(raw))
+				}
+
+				r, out := readTestWav(t, path)
+				if r.PCM.AudioFormat != tc.format || int(r.PCM.NumChannels) != ch || r.PCM.SampleRate != 16000 || r.PCM.BitsPerSample != tc.bits {
+					t.Fatalf("формат %+v", r.PCM)
+				}
+				if len(out) != len(in) { t.Fatalf("прочитано %d сэмплов, записано %d", len(out), len(in)) }
+				// квантование: полшага плюс разница шкал кодера (2^(n-1)-1) и декодера (2^(n-1))
+				tol := math.Max(2/math.Ldexp(1, int(tc.bits)-1), 1e-6)
+				if tc.format == wavFormatFloat { tol = 0 }
+				for i := range in {
+					if d := math.Abs(float64(out[i] - in[i])); d > tol {
+						t.Fatalf("сэмпл %d: %g, ожидалось %g (±%g)", i, out[i], in[i], tol)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWavRF64Header(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		format uint16
+	}{{"pcm16", wavFormatPCM}, {"float32", wavFormatFloat}} {
+		t.Run(tc.name, func(t *testing.T) {
+			const frames, ch = 1000, 2
+			path := filepath.Join(t.TempDir(), "x.wav")
+			pcm := pcmFormat(tc.format, 48000, ch, 16)
+			if tc.format == wavFormatFloat { pcm = pcmFormat(tc.format, 48000, ch, 32) }
+			in := testSignal(frames, ch)
+			var junkOff, factOff, dataOff int64
+			writeTestWav(t, path, pcm, in, func(w *wavWriter) {
+				w.rf64At = 0 // любой размер «больше» предела — заголовок переписывается в RF64
+				junkOff, factOff, dataOff = w.junkOff, w.factOff, w.dataOff
+			})
+			raw, err := os.ReadFile(path)
+			if err != nil { t.Fatal(err) }
+			le := binary.LittleEndian
+			dataSize := uint64(frames * ch * int(pcm.BitsPerSample/8))
+
+			if string(raw[:4]) != "RF64" { t.Fatalf("id %q, ожидался RF64", raw[:4]) }
+			if le.Uint32(raw[4:]) != riffMaxSize { t.Fatalf("RIFF size %#x, ожидался %#x", le.Uint32(raw[4:]), uint32(riffMaxSize)) }
+			ds := raw[junkOff:]
+			if string(ds[:4]) != "ds64" || le.Uint32(ds[4:]) != ds64Size { t.Fatalf("на месте JUNK: %q size %d", ds[:4], le.Uint32(ds[4:])) }
+			if got := le.Uint64(ds[8:]); got != uint64(len(raw)-8) { t.Fatalf("ds64 riffSize %d, файл %d", got, len(raw)) }
+			if got := le.Uint64(ds[16:]); got != dataSize { t.Fatalf("ds64 dataSize %d, ожидалось %d", got, dataSize) }
+			if got := le.Uint64(ds[24:]); got != frames { t.Fatalf("ds64 sampleCount %d, ожидалось %d", got, frames) }
+			if !bytes.Equal(raw[dataOff:dataOff+4], []byte("data")) || le.Uint32(raw[dataOff+4:]) != riffMaxSize {
+				t.Fatalf("data: %q size %#x", raw[dataOff:dataOff+4], le.Uint32(raw[dataOff+4:]))
+			}
+			if tc.format == wavFormatFloat {
+				if factOff == 0 || le.Uint32(raw[factOff+8:]) != riffMaxSize { t.Fatalf("fact не переписан в RF64") }
+			}
+
+			// чтение идёт через ds64
+			_, out := readTestWav(t, path)
+			if len(out) != frames*ch { t.Fatalf("прочитано %d сэмплов, ожидалось %d", len(out), frames*ch) }
+			for i := range in {
+				if d := math.Abs(float64(out[i] - in[i])); d > 1e-4 { t.Fatalf("сэмпл %d: %g, ожидалось %g", i, out[i], in[i]) }
+			}
+		})
+	}
+}
+
+func TestWavZeroSampleRate(t *testing.T) {
+	// частота 0 Гц в fmt — ошибка открытия, а не деление на ноль в ресемплере
+	path := filepath.Join(t.TempDir(), "x.wav")
+	writeTestWav(t, path, pcmFormat(wavFormatPCM, 16000, 1, 16), testSignal(100, 1), nil)
+	raw, err := os.ReadFile(path)
+	if err != nil { t.Fatal(err) }
+	i := bytes.Index(raw, []byte("fmt "))
+	binary.LittleEndian.PutUint32(raw[i+8+4:], 0)
+	if err := os.WriteFile(path, raw, 0644); err != nil { t.Fatal(err) }
+	if _, err := openWav(path, false); err == nil { t.Fatalf("openWav: нет ошибки для частоты 0 Гц") }
+}
+
+func TestWavRepairTrailingChunks(t *testing.T) {
+	const frames, ch = 999, 1
+	in := testSignal(frames, ch)
+	id3 := append([]byte("id3 \x05\x00\x00\x00ID3\x04\x00"), 0) // нечётный размер + выравнивание
+	cases := []struct {
+		name   string
+		marker bool   // cue + LIST/adtl после data (пишет wavWriter)
+		extra  []byte // дописано в конец файла
+		note   string // ожидаемое в Repaired
+	}{
+		{"no-chunks", false, nil, "по размеру файла"},
+		{"list", true, nil, `"cue "`},
+		{"id3", false, id3, `"id3 "`},
+		{"list-id3", true, id3, `"cue "`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "x.wav")
+			writeTestWav(t, path, pcmFormat(wavFormatPCM, 16000, ch, 16), in, func(w *wavWriter) {
+				if tc.marker { w.AddMarker(10, "seg") }
+			})
+			raw, err := os.ReadFile(path)
+			if err != nil { t.Fatal(err) }
+			// запись оборвалась до финализации: размер data 0
+			i := bytes.Index(raw, []byte("data"))
+			binary.LittleEndian.PutUint32(raw[i+4:], 0)
+			raw = append(raw, tc.extra...)
+			if err := os.WriteFile(path, raw, 0644); err != nil { t.Fatal(err) }
+
+			r, err := openWav(path, true)
+			if err != nil { t.Fatal(err) }
+			defer r.Close()
+			if !strings.Contains(r.Repaired, tc.note) { t.Fatalf("Repaired %q, ожидалось %s", r.Repaired, tc.note) }
+			if r.Samples() != frames*ch { t.Fatalf("%d сэмплов, ожидалось %d (%s)", r.Samples(), frames*ch, r.Repaired) }
+			out := make([]float32, r.Samples())
+			for got := 0; got < len(out); {
+				n, err := r.Read(out[got:])
+				if err != nil { t.Fatalf("read at %d: %v", got, err) }
+				got += n
+			}
+			for k := range in {
+				if d := math.Abs(float64(out[k] - in[k])); d > 1e-4 { t.Fatalf("сэмпл %d: %g, ожидалось %g", k, out[k], in[k]) }
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\window.go
+// Package: app
+// Назначение: Окно по реальному времени (--from/--to): отбор пересекающихся файлов и обрезка до сэмпла.
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// parseWallClock — местное время "2025-01-01 12:00:00[.000]" (также через T, без секунд или только дата),
+// RFC 3339 с часовым поясом или только время суток "14:00[:00[.000]]" — тогда clock=true,
+// и дата подставляется позже (onDay).
+func parseWallClock(s string) (t time.Time, clock bool, err error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil { return t, false, nil }
+	for _, layout := range []string{
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04",
+		"2006-01-02T15:04",
+		"2006-01-02",
+	} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil { return t, false, nil }
+	}
+	for _, layout := range []string{"15:04:05.999999999", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil { return t, true, nil }
+	}
+	return time.Time{}, false, fmt.Errorf("время %q: ожидается ГГГГ-ММ-ДД[ чч:мм[:сс[.ммм]]], чч:мм[:сс[.ммм]] или RFC 3339", s)
+}
+
+// onDay — время суток t в день day (местное время).
+func onDay(t, day time.Time) time.Time {
+	y, m, d := day.In(time.Local).Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
+
+// applyWindow оставляет файлы, пересекающие [from; to), и срезает выступающие части.
+// Нулевое from/to — без ограничения с этой стороны. Время начала — как в fileStart.
+func applyWindow(files []fileInfo, from, to time.Time, rate int, src string, np *nameTimeParser, repair bool) ([]fileInfo, int64, error) {
+	frames := func(d time.Duration) int64 { return int64(math.Round(d.Seconds() * float64(rate))) }
+	dur := func(f int64) time.Duration { return time.Duration(float64(f) / float64(rate) * float64(time.Second)) }
+
+	var trimmed int64
+	kept := files[:0:0]
+	for _, fi := range files {
+		h, err := probeWavHeader(fi.Path, repair)
+		if err != nil { return nil, 0, fmt.Errorf("%s: %w", fi.Path, err) }
+		start, _ := fileStart(fi, h, src, np)
+		// длина на выходной частоте — как у segment (ресемплер округляет вверх)
+		total := (h.Frames*int64(rate) + int64(h.PCM.SampleRate) - 1) / int64(h.PCM.SampleRate)
+
+		t0 := start.Add(dur(fi.TrimIn))
+		t1 := start.Add(dur(total - fi.TrimOut))
+		if (!to.IsZero() && !t0.Before(to)) || (!from.IsZero() && !t1.After(from)) { continue }
+		in, out := fi.TrimIn, fi.TrimOut
+		if !from.IsZero() && from.After(t0) { fi.TrimIn += frames(from.Sub(t0)) }
+		if !to.IsZero() && to.Before(t1) { fi.TrimOut += frames(t1.Sub(to)) }
+		if fi.TrimIn+fi.TrimOut >= total { continue }
+		trimmed += fi.TrimIn - in + fi.TrimOut - out
+		fi.Start = start
+		kept = append(kept, fi)
+	}
+	return kept, trimmed, nil
+}
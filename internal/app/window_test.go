@@ -0,0 +1,78 @@
+package app
+
+// C:\_Projects_Go\AcousticMerge\internal\app\window_test.go
+// Package: app
+// Назначение: Тесты окна --from/--to: разбор времени (с датой и без) и обрезка файлов до сэмпла.
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseWallClock(t *testing.T) {
+	local := func(y, mo, d, h, mi, s, ms int) time.Time {
+		return time.Date(y, time.Month(mo), d, h, mi, s, ms*int(time.Millisecond), time.Local)
+	}
+	cases := []struct {
+		in    string
+		want  time.Time
+		clock bool
+	}{
+		{"2025-01-01 12:00:00.250", local(2025, 1, 1, 12, 0, 0, 250), false},
+		{"2025-01-01T12:30", local(2025, 1, 1, 12, 30, 0, 0), false},
+		{"2025-01-01", local(2025, 1, 1, 0, 0, 0, 0), false},
+		{"2025-01-01T12:00:00Z", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), false},
+		{"14:00", local(0, 1, 1, 14, 0, 0, 0), true},
+		{" 15:30:05.5 ", local(0, 1, 1, 15, 30, 5, 500), true},
+	}
+	for _, tc := range cases {
+		got, clock, err := parseWallClock(tc.in)
+		if err != nil || !got.Equal(tc.want) || clock != tc.clock {
+			t.Fatalf("%q: %v clock=%v err=%v; ожидалось %v clock=%v", tc.in, got, clock, err, tc.want, tc.clock)
+		}
+	}
+	for _, s := range []string{"", "14", "25:00", "2025-13-01", "завтра"} {
+		if _, _, err := parseWallClock(s); err == nil { t.Fatalf("%q: ожидалась ошибка", s) }
+	}
+
+	// время суток — в день первого файла, с сохранением долей секунды
+	clock, _, _ := parseWallClock("14:00:00.5")
+	day := time.Date(2025, 3, 30, 23, 59, 0, 0, time.Local)
+	if got, want := onDay(clock, day), time.Date(2025, 3, 30, 14, 0, 0, 5e8, time.Local); !got.Equal(want) {
+		t.Fatalf("onDay: %v, ожидалось %v", got, want)
+	}
+}
+
+func TestApplyWindow(t *testing.T) {
+	const rate = 16000
+	dir := t.TempDir()
+	np, err := newNameTimeParser("rec_YYYYMMDD_hhmmss")
+	if err != nil { t.Fatal(err) }
+	var files []fileInfo
+	for _, n := range []string{"rec_20250101_135958.wav", "rec_20250101_140000.wav", "rec_20250101_140001.wav", "rec_20250101_140002.wav", "rec_20250101_140010.wav"} {
+		p := filepath.Join(dir, n)
+		writeTestWav(t, p, pcmFormat(wavFormatPCM, rate, 1, 16), make([]float32, rate), nil) // 1 с
+		files = append(files, fileInfo{Name: n, Path: p})
+	}
+	at := func(s string) time.Time {
+		c, _, err := parseWallClock(s)
+		if err != nil { t.Fatal(err) }
+		return onDay(c, time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local))
+	}
+
+	kept, trimmed, err := applyWindow(files, at("14:00:00.5"), at("14:00:02.25"), rate, "name", np, false)
+	if err != nil { t.Fatal(err) }
+	if len(kept) != 3 || kept[0].Name != "rec_20250101_140000.wav" || kept[2].Name != "rec_20250101_140002.wav" {
+		t.Fatalf("оставлено %d файлов: %+v", len(kept), kept)
+	}
+	if kept[0].TrimIn != rate/2 || kept[0].TrimOut != 0 || kept[1].TrimIn != 0 || kept[1].TrimOut != 0 || kept[2].TrimIn != 0 || kept[2].TrimOut != rate*3/4 {
+		t.Fatalf("обрезка: %d/%d, %d/%d, %d/%d", kept[0].TrimIn, kept[0].TrimOut, kept[1].TrimIn, kept[1].TrimOut, kept[2].TrimIn, kept[2].TrimOut)
+	}
+	if trimmed != rate/2+rate*3/4 { t.Fatalf("срезано %d кадров", trimmed) }
+
+	// открытое с одной стороны окно; конец файла ровно на from — не пересекает
+	kept, _, err = applyWindow(files, at("13:59:59"), time.Time{}, rate, "name", np, false)
+	if err != nil { t.Fatal(err) }
+	if len(kept) != 4 { t.Fatalf("from 13:59:59: оставлено %d файлов, ожидалось 4", len(kept)) }
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 )
 
 const Version = "v0.3.0"
@@ -18,26 +19,63 @@ const Version = "v0.3.0"
 type OrderBy string
 
 const (
-	OrderByName  OrderBy = "name"
-	OrderByMTime OrderBy = "mtime"
+	OrderByName      OrderBy = "name"
+	OrderByMTime     OrderBy = "mtime"
+	OrderByTimestamp OrderBy = "timestamp"
+	OrderByNatural   OrderBy = "natural"
 )
 
 type Config struct {
-	Src           string
-	Out           string
-	GainPct       float64
-	Order         OrderBy
-	StrictFormat  bool
-	Resample      int
-	NormalizeDB   float64
-	DoNormalize   bool
-	CrossfadeMS   int
-	DryRun        bool
-	BarWidth      int
-	NoColor       bool
-	NoEmoji       bool
-	MergeNow      bool
-	ShowOnlyHelp  bool
+	Src              string
+	Out              string
+	GainPct          float64
+	Order            OrderBy
+	StrictFormat     bool
+	Resample         int
+	OutBits          int
+	OutFloat         bool
+	NormalizeDB      float64
+	DoNormalize      bool
+	TruePeak         bool
+	LoudnessLUFS     float64
+	DoLoudness       bool
+	LimiterCeiling   float64
+	LimiterAttackMS  float64
+	LimiterReleaseMS float64
+	DoLimiter        bool
+	Dither           string
+	SkipSilentDB     float64
+	DoSkipSilent     bool
+	TrimSilenceDB    float64
+	DoTrimSilence    bool
+	FillGaps         bool
+	GapToleranceMS   int
+	TimeSource       string
+	NameTime         string
+	NameRegex        string
+	List             string
+	From             string
+	To               string
+	Include          []string
+	Exclude          []string
+	MaxDepth         int
+	FollowSymlinks   bool
+	CrossfadeMS      int
+	CrossfadeCurve   string
+	OnError          string
+	Repair           bool
+	QuarantineDir    string
+	GroupBy          string
+	SplitEvery       string
+	SplitSize        string
+	Markers          bool
+	Index            string
+	DryRun           bool
+	BarWidth         int
+	NoColor          bool
+	NoEmoji          bool
+	MergeNow         bool
+	ShowOnlyHelp     bool
 }
 
 // ---------- Цвета/эмодзи/логгеры ----------
@@ -74,6 +112,10 @@ type UIAPI struct {
 	PrintBar func(label string, cur, total int)
 	EndBar   func()
 	Banner   func(title string)
+
+	// PrintTable — таблица с отступом как у PrintKV: align по колонке 'l' (влево) или 'r' (вправо),
+	// заголовок серым, последняя колонка без выравнивания.
+	PrintTable func(align string, head []string, rows [][]string)
 }
 
 var API UIAPI // инициализируется в ParseArgsAndSetup
@@ -115,10 +157,42 @@ func showHelp(noColor bool) {
 	fmt.Println(col(noColor, "Параметры:", cCyan))
 	fmt.Printf("  --src <путь>         Папка с WAV-файлами (рекурсивный сбор). По умолчанию: %s\n", defSrc)
 	fmt.Printf("  --out <путь>         Итоговый WAV. По умолчанию: %s\n", defOut)
+	fmt.Println("  --list <файл>        Склеить файлы из списка (M3U, текст или CSV: path,gain_db,trim_in,trim_out,gap)")
+	fmt.Println("  --include <маска>    Брать только подходящие файлы (повторяемый), напр. \"2025*.wav\"")
+	fmt.Println("  --exclude <маска>    Пропускать файлы/папки (повторяемый), напр. _rejected или \"**/_preview/**\"")
+	fmt.Println("  --max-depth <N>      Глубина обхода подпапок (0 = только --src, по умолч. без ограничения)")
+	fmt.Println("  --follow-symlinks    Заходить в папки по ссылкам (файлы-ссылки берутся всегда)")
 	fmt.Println("  --gain-pct <число>   Усиление в процентах (100=как есть, 150=×1.5, 200=×2.0)")
 	fmt.Println("  --normalize <дБ>     Пик-нормализация до уровня (дБFS), напр. -1.0")
-	fmt.Println("  --order name|mtime   Порядок: по имени или по времени изменения")
+	fmt.Println("  --true-peak          Нормализовать по true-peak (×4 oversampling, дБTP) вместо пика сэмплов")
+	fmt.Println("  --loudness-lufs <LUFS>  Нормализация громкости по EBU R128, напр. -23 или -16")
+	fmt.Println("  --limiter-ceiling <дБ>  Look-ahead лимитер с потолком (дБFS), напр. -0.3; вместо клиппирования")
+	fmt.Println("  --limiter-attack-ms <мс>, --limiter-release-ms <мс>  Атака/восстановление лимитера (5 / 50)")
+	fmt.Println("  --skip-silent-db <дБ>   Пропускать файлы с RMS ниже порога (дБFS), напр. -60")
+	fmt.Println("  --trim-silence-db <дБ>  Срезать тихие начало/конец файлов (порог дБFS), напр. -55")
+	fmt.Println("  --from <время>, --to <время>  Окно по реальному времени, напр. \"2025-01-01 12:00:00\" или \"14:00\"")
+	fmt.Println("                       (без даты — день первого файла); без пропусков по времени — вместе с --fill-gaps")
+	fmt.Println("  --fill-gaps          Заполнять паузы между файлами тишиной по времени начала записи")
+	fmt.Println("  --gap-tolerance-ms <мс>  Паузы короче допуска не заполняются (по умолч. 1000)")
+	fmt.Println("  --time-source <src>  Время начала: auto|name|bext|mtime (auto: имя → bext → mtime)")
+	fmt.Println("  --name-time <шаблон> Время из имени файла, напр. rec_YYYYMMDD_hhmmss_fff; текст с буквами — в кавычках: 'session'_YYYYMMDD")
+	fmt.Println("  --name-regex <re>    То же регулярным выражением с группами (?P<YYYY>…)(?P<MM>…)(?P<DD>…)…")
+	fmt.Println("  --order name|natural|mtime|timestamp  Порядок: по имени, по имени с числами (seg_2 < seg_10),")
+	fmt.Println("                       по времени изменения или по времени из имени")
 	fmt.Println("  --crossfade-ms <мс>  Лёгкий фейд на стыках (0=выкл)")
+	fmt.Println("  --crossfade-curve <форма>  linear|equal-power|s-curve|log (по умолч. linear)")
+	fmt.Println("  --resample <Гц>      Привести все файлы к частоте (windowed-sinc), напр. 48000")
+	fmt.Println("  --out-bits <N>       Разрядность итога: 8|16|24|32 (0 = как у источника, по умолч. 16)")
+	fmt.Println("  --out-float          Итог в IEEE float 32 бит (gain/кроссфейды без клиппирования)")
+	fmt.Println("  --dither <вид>       Дизеринг при переводе в целый PCM: none|tpdf|shaped (по умолч. none)")
+	fmt.Println("  --on-error <вид>     Повреждённый файл: abort (по умолч.) | skip | quarantine (перенос в папку)")
+	fmt.Println("  --repair             Восстанавливать оборванные записи: длина data по размеру файла (до чанков LIST/id3 в конце)")
+	fmt.Println("  --quarantine-dir <папка>  Куда переносить при quarantine (по умолч. _quarantine рядом с --out)")
+	fmt.Println("  --group-by dir|day|hour  Отдельный итог на каждую папку, день или час записи")
+	fmt.Println("  --split-every <длит.>  Делить итог на части: merged_part001.wav, … напр. 1h или 30m")
+	fmt.Println("  --split-size <размер>  Делить итог по размеру файла, напр. 2GB (K/M/G = 1024)")
+	fmt.Println("  --markers=false      Не писать метки (cue) с именами файлов на стыках")
+	fmt.Println("  --index <вид>        Индекс сегментов merged.index.json/.csv: none|json|csv|both (по умолч. none)")
 	fmt.Println("  --dry-run            Только проверка (без записи файла)")
 	fmt.Println("  --bar-width <N>      Ширина прогресс-бара (80 по умолчанию)")
 	fmt.Println("  --no-color           Отключить цвет")
@@ -145,6 +219,12 @@ func showHelp(noColor bool) {
 
 // ---------- Парсинг/алиасы и настройка UI ----------
 
+// listFlag — повторяемый строковый флаг (--include a --include b).
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
 func ParseArgsAndSetup() (*Config, bool) {
 	cfg := &Config{}
 
@@ -171,8 +251,39 @@ func ParseArgsAndSetup() (*Config, bool) {
 		flagOrder       string
 		flagStrict      bool
 		flagResample    int
+		flagOutBits     int
+		flagOutFloat    bool
 		flagNormalizeDB float64
+		flagLoudness    float64
+		flagTruePeak    bool
+		flagLimCeil     float64
+		flagLimAttack   float64
+		flagLimRelease  float64
+		flagDither      string
+		flagSkipSilent  float64
+		flagTrimSilence float64
+		flagFillGaps    bool
+		flagGapTol      int
+		flagTimeSource  string
+		flagNameTime    string
+		flagNameRegex   string
+		flagList        string
+		flagFrom        string
+		flagTo          string
+		flagInclude     listFlag
+		flagExclude     listFlag
+		flagMaxDepth    int
+		flagSymlinks    bool
 		flagCrossfadeMS int
+		flagFadeCurve   string
+		flagOnError     string
+		flagRepair      bool
+		flagQuarantine  string
+		flagGroupBy     string
+		flagSplitEvery  string
+		flagSplitSize   string
+		flagMarkers     bool
+		flagIndex       string
 		flagDryRun      bool
 		flagNoColor     bool
 		flagBarW        int
@@ -180,13 +291,44 @@ func ParseArgsAndSetup() (*Config, bool) {
 	)
 
 	flag.StringVar(&flagSrc, "src", defSrc, "Папка с WAV-файлами (рекурсивный сбор)")
-	flag.StringVar(&flagOut, "out", defOut, "Путь к итоговому файлу (если занят — merged_1.wav и т.д.)")
+	flag.StringVar(&flagOut, "out", defOut, "Путь к итоговому файлу (если занят он или его индекс — merged_1.wav и т.д.)")
+	flag.StringVar(&flagList, "list", "", "Список файлов вместо обхода --src: .m3u/.m3u8, текст (путь на строку) или .csv (path,gain_db,trim_in,trim_out,gap)")
+	flag.Var(&flagInclude, "include", "Маска файлов для сбора (повторяемый); без \"/\" — по имени, с \"/\" — по пути от --src, ** — любые папки")
+	flag.Var(&flagExclude, "exclude", "Маска исключаемых файлов и папок (повторяемый), напр. _rejected")
+	flag.IntVar(&flagMaxDepth, "max-depth", -1, "Глубина обхода подпапок: 0 = только --src, -1 = без ограничения")
+	flag.BoolVar(&flagSymlinks, "follow-symlinks", false, "Заходить в папки по символическим ссылкам (файлы-ссылки берутся всегда)")
 	flag.Float64Var(&flagGainPct, "gain-pct", 100, "Усиление в процентах: 100=как есть, 150=×1.5, 200=×2.0")
-	flag.StringVar(&flagOrder, "order", string(OrderByName), "Порядок: name|mtime")
-	flag.BoolVar(&flagStrict, "strict-format", true, "Требовать одинаковый формат (PCM16/SR/каналы). Иначе ошибка")
-	flag.IntVar(&flagResample, "resample", 0, "Привести sample rate к указанному (не реализовано)")
+	flag.Float64Var(&flagSkipSilent, "skip-silent-db", math.NaN(), "Пропускать файлы, чей RMS (до gain) ниже порога, дБFS")
+	flag.Float64Var(&flagTrimSilence, "trim-silence-db", math.NaN(), "Срезать тихие края файлов: кадры тише порога (дБFS) в начале и конце")
+	flag.StringVar(&flagFrom, "from", "", "Начало окна (местное время): ГГГГ-ММ-ДД[ чч:мм[:сс[.ммм]]], чч:мм[:сс[.ммм]] (в день первого файла) или RFC 3339")
+	flag.StringVar(&flagTo, "to", "", "Конец окна (не включая), формат как у --from; итог покрывает окно по реальному времени только с --fill-gaps")
+	flag.BoolVar(&flagFillGaps, "fill-gaps", false, "Заполнять паузы между файлами тишиной, чтобы ось времени итога совпадала с реальной")
+	flag.IntVar(&flagGapTol, "gap-tolerance-ms", 1000, "Допуск паузы (мс): меньшие расхождения не заполняются")
+	flag.StringVar(&flagTimeSource, "time-source", "auto", "Источник времени начала файла: auto|name|bext|mtime")
+	flag.StringVar(&flagNameTime, "name-time", "", "Шаблон времени в имени файла: YYYY YY MM DD hh mm ss fff, напр. rec_YYYYMMDD_hhmmss_fff; текст с буквами — в кавычках: 'session'_YYYYMMDD'T'hhmmss")
+	flag.StringVar(&flagNameRegex, "name-regex", "", "Время в имени файла регулярным выражением с группами YYYY|YY, MM, DD, hh, mm, ss, fff")
+	flag.StringVar(&flagOrder, "order", string(OrderByName), "Порядок: name|natural|mtime|timestamp (время из имени по --name-time/--name-regex)")
+	flag.BoolVar(&flagStrict, "strict-format", true, "Требовать одинаковый формат (PCM/float, разрядность, SR, каналы). Иначе ошибка; без него эталон, сменившийся после отбрасывания первого файла, должен совпасть по SR и каналам")
+	flag.IntVar(&flagResample, "resample", 0, "Привести sample rate всех файлов к указанному (Гц). 0 = частота первого файла")
+	flag.IntVar(&flagOutBits, "out-bits", 16, "Разрядность итогового PCM: 8|16|24|32. 0 = как у первого файла")
+	flag.BoolVar(&flagOutFloat, "out-float", false, "Записать итог в IEEE float 32 бит (без клиппирования)")
 	flag.Float64Var(&flagNormalizeDB, "normalize", math.NaN(), "Пик-нормализация до уровня (дБFS), напр. -1.0")
+	flag.BoolVar(&flagTruePeak, "true-peak", false, "Нормализация по true-peak (×4 oversampling, BS.1770) вместо пика сэмплов")
+	flag.Float64Var(&flagLoudness, "loudness-lufs", math.NaN(), "Нормализация интегральной громкости (ITU-R BS.1770 / EBU R128) до LUFS, напр. -23")
+	flag.Float64Var(&flagLimCeil, "limiter-ceiling", math.NaN(), "Look-ahead brickwall-лимитер: потолок (дБFS), напр. -0.3")
+	flag.Float64Var(&flagLimAttack, "limiter-attack-ms", 5, "Атака (look-ahead) лимитера, мс")
+	flag.Float64Var(&flagLimRelease, "limiter-release-ms", 50, "Восстановление лимитера, мс")
 	flag.IntVar(&flagCrossfadeMS, "crossfade-ms", 0, "Кроссфейд на стыках (мс). 0 = без кроссфейда")
+	flag.StringVar(&flagFadeCurve, "crossfade-curve", "linear", "Форма кроссфейда: linear|equal-power|s-curve|log")
+	flag.StringVar(&flagDither, "dither", "none", "Дизеринг при float → целый PCM: none|tpdf|shaped (TPDF + noise shaping)")
+	flag.StringVar(&flagOnError, "on-error", "abort", "Повреждённый входной файл: abort (остановить) | skip (пропустить) | quarantine (пропустить и перенести)")
+	flag.BoolVar(&flagRepair, "repair", false, "Восстановление: нулевой или больший файла размер data → длина до чанков LIST/id3/cue в конце файла или по размеру файла (только целые кадры)")
+	flag.StringVar(&flagQuarantine, "quarantine-dir", "", "Папка карантина для --on-error quarantine (по умолчанию _quarantine рядом с --out)")
+	flag.StringVar(&flagGroupBy, "group-by", "", "Отдельный итог на группу: dir (папка) | day | hour (время начала записи), напр. merged_2025-01-01.wav")
+	flag.StringVar(&flagSplitEvery, "split-every", "", "Делить итог на части заданной длительности (merged_part001.wav, …), напр. 1h")
+	flag.StringVar(&flagSplitSize, "split-size", "", "Делить итог на части не больше заданного размера файла, напр. 2GB (K/M/G/T = 1024)")
+	flag.BoolVar(&flagMarkers, "markers", true, "Метки (cue + LIST/adtl) с именем файла на каждом стыке")
+	flag.StringVar(&flagIndex, "index", "none", "Индекс сегментов рядом с итогом (<имя>.index.json/.csv): none|json|csv|both")
 	flag.BoolVar(&flagDryRun, "dry-run", false, "Только проверить и вывести сводку (без записи)")
 
 	flag.BoolVar(&flagNoColor, "no-color", false, "Отключить цветной вывод")
@@ -211,9 +353,44 @@ func ParseArgsAndSetup() (*Config, bool) {
 	cfg.Order = OrderBy(strings.ToLower(flagOrder))
 	cfg.StrictFormat = flagStrict
 	cfg.Resample = flagResample
+	cfg.OutBits = flagOutBits
+	cfg.OutFloat = flagOutFloat
 	cfg.NormalizeDB = flagNormalizeDB
 	cfg.DoNormalize = !math.IsNaN(flagNormalizeDB)
+	cfg.TruePeak = flagTruePeak
+	cfg.LoudnessLUFS = flagLoudness
+	cfg.DoLoudness = !math.IsNaN(flagLoudness)
+	cfg.LimiterCeiling = flagLimCeil
+	cfg.LimiterAttackMS = flagLimAttack
+	cfg.LimiterReleaseMS = flagLimRelease
+	cfg.DoLimiter = !math.IsNaN(flagLimCeil)
 	cfg.CrossfadeMS = flagCrossfadeMS
+	cfg.CrossfadeCurve = strings.ToLower(flagFadeCurve)
+	cfg.Dither = strings.ToLower(flagDither)
+	cfg.SkipSilentDB = flagSkipSilent
+	cfg.DoSkipSilent = !math.IsNaN(flagSkipSilent)
+	cfg.TrimSilenceDB = flagTrimSilence
+	cfg.DoTrimSilence = !math.IsNaN(flagTrimSilence)
+	cfg.FillGaps = flagFillGaps
+	cfg.GapToleranceMS = flagGapTol
+	cfg.TimeSource = strings.ToLower(flagTimeSource)
+	cfg.NameTime = flagNameTime
+	cfg.NameRegex = flagNameRegex
+	cfg.List = flagList
+	cfg.From = flagFrom
+	cfg.To = flagTo
+	cfg.Include = flagInclude
+	cfg.Exclude = flagExclude
+	cfg.MaxDepth = flagMaxDepth
+	cfg.FollowSymlinks = flagSymlinks
+	cfg.OnError = strings.ToLower(flagOnError)
+	cfg.QuarantineDir = flagQuarantine
+	cfg.Repair = flagRepair
+	cfg.GroupBy = strings.ToLower(flagGroupBy)
+	cfg.SplitEvery = flagSplitEvery
+	cfg.SplitSize = flagSplitSize
+	cfg.Markers = flagMarkers
+	cfg.Index = flagIndex
 	cfg.DryRun = flagDryRun
 	cfg.BarWidth = flagBarW
 	cfg.NoColor = flagNoColor
@@ -245,6 +422,26 @@ func makeUI(noColor, noEmoji bool, barW int) UIAPI {
 		if len(k) < pad { k = k + strings.Repeat(" ", pad-len(k)) }
 		fmt.Printf("   %s %s\n", col(noColor, k, cGray), v)
 	}
+	printTable := func(align string, head []string, rows [][]string) {
+		width := make([]int, len(head))
+		for _, r := range append([][]string{head}, rows...) {
+			for i, c := range r {
+				if i < len(width) { width[i] = max(width[i], utf8.RuneCountInString(c)) }
+			}
+		}
+		line := func(r []string) string {
+			cells := make([]string, len(r))
+			for i, c := range r {
+				pad := ""
+				if i < len(r)-1 && i < len(width) { pad = strings.Repeat(" ", width[i]-utf8.RuneCountInString(c)) }
+				cells[i] = c + pad
+				if i < len(align) && align[i] == 'r' { cells[i] = pad + c }
+			}
+			return strings.TrimRight(strings.Join(cells, "  "), " ")
+		}
+		fmt.Printf("   %s\n", col(noColor, line(head), cGray))
+		for _, r := range rows { fmt.Printf("   %s\n", line(r)) }
+	}
 	printBar := func(label string, cur, total int) {
 		if total <= 0 { return }
 		if barW < 10 { barW = 10 }
@@ -281,6 +478,6 @@ func makeUI(noColor, noEmoji bool, barW int) UIAPI {
 
 	return UIAPI{
 		LogInfo: logInfo, LogOK: logOK, LogWarn: logWarn, LogErr: logErr,
-		PrintKV: printKV, PrintBar: printBar, EndBar: endBar, Banner: banner,
+		PrintKV: printKV, PrintTable: printTable, PrintBar: printBar, EndBar: endBar, Banner: banner,
 	}
 }